@@ -1,14 +1,29 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"api-rabbitmq/internal/application/services"
 	"api-rabbitmq/internal/application/usecases"
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+	"api-rabbitmq/internal/infrastructure/archive"
 	"api-rabbitmq/internal/infrastructure/config"
+	"api-rabbitmq/internal/infrastructure/database/inmemory"
 	"api-rabbitmq/internal/infrastructure/database/mongodb"
+	"api-rabbitmq/internal/infrastructure/database/mongodb/migrations"
+	"api-rabbitmq/internal/infrastructure/encryption"
+	"api-rabbitmq/internal/infrastructure/health"
 	"api-rabbitmq/internal/infrastructure/http/handlers"
 	"api-rabbitmq/internal/infrastructure/messagebroker/rabbitmq"
 	"api-rabbitmq/internal/interfaces/api"
@@ -21,51 +36,307 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	entities.SetPIIMasking(cfg.PIIMaskingEnabled())
+	if !cfg.PIIMaskingEnabled() {
+		log.Printf("Warning: PII masking disabled, personal data will appear in logs")
+	}
+
 	// Validar configurações
 	if err := cfg.Database.Validate(); err != nil {
 		log.Printf("Warning: Database configuration error: %v", err)
 	}
 
-	// Inicializar repositório
-	userRepo, err := mongodb.NewUserRepository(cfg.Database.GetConnectionString())
+	if err := cfg.Retention.Validate(); err != nil {
+		log.Fatalf("Invalid retention configuration: %v", err)
+	}
+	if err := cfg.Privacy.Validate(); err != nil {
+		log.Fatalf("Invalid privacy configuration: %v", err)
+	}
+	if err := cfg.Tenancy.Validate(); err != nil {
+		log.Fatalf("Invalid tenancy configuration: %v", err)
+	}
+	if err := cfg.Server.Validate(); err != nil {
+		log.Fatalf("Invalid server configuration: %v", err)
+	}
+	erasureMode, err := repositories.ParseErasureMode(cfg.Privacy.ErasureMode)
 	if err != nil {
-		log.Printf("Warning: MongoDB not available: %v", err)
-		userRepo = nil
-	} else {
-		defer userRepo.Close()
+		log.Fatalf("Invalid privacy configuration: %v", err)
+	}
+
+	// Inicializar repositórios
+	var userRepo repositories.UserRepository
+	var receiptRepo repositories.ErasureReceiptRepository
+	var auditRepo repositories.AuditRepository
+	var erasureTargets []repositories.ErasureTarget
+
+	fieldCipher, err := encryption.NewFieldCipherFromConfig(&cfg.Privacy)
+	if err != nil {
+		log.Fatalf("Invalid encryption configuration: %v", err)
+	}
+	if fieldCipher == nil {
+		log.Printf("Warning: field encryption disabled, document numbers are stored in plain text")
+	}
+
+	// Verificações de dependências do /readyz
+	healthRegistry := health.NewRegistry(cfg.Server.HealthCheckTimeout)
+
+	switch cfg.Database.Driver {
+	case config.DriverInMemory:
+		log.Printf("Warning: using in-memory repositories, data is lost on restart")
+		if cfg.Retention.Enabled() && cfg.Retention.Mode == string(usecases.RetentionTTL) {
+			log.Printf("Warning: TTL retention is not supported by the in-memory driver")
+		}
+
+		auditRepo = inmemory.NewAuditRepository()
+		userRepo, err = inmemory.NewUserRepository(&cfg.Database, inmemory.UserRepositoryOptions{Audit: auditRepo})
+		if err != nil {
+			log.Fatalf("Failed to initialize user repository: %v", err)
+		}
+
+		receiptRepo = inmemory.NewErasureReceiptRepository()
+		erasureTargets = append(erasureTargets, userRepo, auditRepo)
+	default:
+		mongoClient, err := mongodb.Connect(&cfg.Database)
+		if err != nil {
+			log.Printf("Warning: MongoDB not available: %v", err)
+			// Sem reconexão: a instância segue indisponível até ser reiniciada
+			healthRegistry.Register("mongodb", true, func(ctx context.Context) (any, error) {
+				return nil, errors.New("not connected since startup")
+			})
+		} else {
+			healthRegistry.Register("mongodb", true, mongodb.Ping(mongoClient))
+			warnPendingMigrations(mongoClient, &cfg.Database, &cfg.Tenancy)
+			if fieldCipher != nil {
+				requireEncryptedDocuments(mongoClient, &cfg.Database, &cfg.Tenancy)
+			}
+
+			auditRepo, err = mongodb.NewAuditRepository(mongoClient, &cfg.Database, fieldCipher, &cfg.Tenancy)
+			if err != nil {
+				log.Fatalf("Failed to initialize audit repository: %v", err)
+			}
+
+			// A auditoria é gravada depois do usuário; eventos perdidos aparecem no /readyz
+			auditFailures := &mongodb.AuditFailures{}
+			healthRegistry.Register("audit", false, auditFailures.Check)
+
+			repoOptions := mongodb.UserRepositoryOptions{Cipher: fieldCipher, Audit: auditRepo, AuditFailures: auditFailures, Tenancy: &cfg.Tenancy}
+			if cfg.Retention.Enabled() && cfg.Retention.Mode == string(usecases.RetentionTTL) {
+				repoOptions.RetentionTTL = cfg.Retention.Period()
+			}
+
+			userRepo, err = mongodb.NewUserRepository(mongoClient, &cfg.Database, repoOptions)
+			if err != nil {
+				log.Fatalf("Failed to initialize user repository: %v", err)
+			}
+
+			receiptRepo, err = mongodb.NewErasureReceiptRepository(mongoClient, &cfg.Database)
+			if err != nil {
+				log.Fatalf("Failed to initialize erasure receipt repository: %v", err)
+			}
+
+			erasureTargets = append(erasureTargets, userRepo, auditRepo)
+		}
 	}
 
 	// Inicializar serviços externos
 	extServices := services.NewExternalServices(&cfg.ExternalAPIs)
+	// Não críticas: o consumidor já trata as falhas das APIs externas por mensagem. Em
+	// cache, para que cada probe do orquestrador não vire uma requisição às APIs.
+	healthRegistry.RegisterCached("document_validation_api", false, cfg.Server.HealthCheckCacheTTL, extServices.CheckDocumentValidation)
+	healthRegistry.RegisterCached("address_api", false, cfg.Server.HealthCheckCacheTTL, extServices.CheckAddressService)
+	// Não há circuit breaker nas chamadas externas; no lugar do estado dos breakers o
+	// relatório traz as métricas dos rate limiters
+	healthRegistry.Register("rate_limiters", false, extServices.CheckRateLimiters)
 
 	// Inicializar use case
-	userUseCase := usecases.NewUserUseCase(userRepo, extServices)
+	userUseCase := usecases.NewUserUseCase(userRepo, auditRepo, extServices)
+	// Os arquivos de retenção guardam dados pessoais e também passam pela eliminação
+	archiver := archive.NewNDJSONArchiver(cfg.Retention.ArchiveDir, cfg.Privacy.SubjectHashKey)
+	erasureTargets = append(erasureTargets, archiver)
+
+	retentionUseCase := usecases.NewRetentionUseCase(userRepo, archiver,
+		usecases.RetentionMode(cfg.Retention.Mode), cfg.Retention.Period())
+	privacyUseCase := usecases.NewPrivacyUseCase(receiptRepo, erasureMode, cfg.Privacy.SubjectHashKey, erasureTargets...)
+
+	// Contexto encerrado por SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Iniciar job de retenção (no modo TTL o próprio MongoDB remove os expirados).
+	// jobs permite ao encerramento aguardar uma execução em andamento antes de fechar o MongoDB.
+	var jobs sync.WaitGroup
+	if cfg.Retention.Enabled() && cfg.Retention.Mode == string(usecases.RetentionArchive) && userRepo != nil {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			usecases.StartRetentionJob(ctx, retentionUseCase, cfg.Retention.Interval, cfg.Tenancy.All())
+		}()
+	}
 
 	// Inicializar RabbitMQ
-	rabbitMQService, err := rabbitmq.NewRabbitMQService(cfg.RabbitMQ.URI, userUseCase)
+	rabbitMQService, err := rabbitmq.NewRabbitMQService(cfg.RabbitMQ.URI, userUseCase, &cfg.Tenancy)
 	if err != nil {
 		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
 	}
-	defer rabbitMQService.Close()
+
+	healthRegistry.Register("rabbitmq", true, rabbitMQService.CheckConnection)
 
 	// Iniciar consumo de mensagens
-	go func() {
-		if err := rabbitMQService.ConsumeMessages(); err != nil {
-			log.Fatalf("Failed to start consuming messages: %v", err)
-		}
-	}()
+	if err := rabbitMQService.ConsumeMessages(); err != nil {
+		log.Fatalf("Failed to start consuming messages: %v", err)
+	}
+	healthRegistry.Register("consumers", true, rabbitMQService.CheckConsumers)
 
 	// Inicializar handlers
 	userHandler := handlers.NewUserHandler(userUseCase, rabbitMQService)
+	privacyHandler := handlers.NewPrivacyHandler(privacyUseCase)
+	retentionHandler := handlers.NewRetentionHandler(retentionUseCase)
+	healthHandler := handlers.NewHealthHandler(healthRegistry, rabbitMQService)
 
 	// Configurar router
-	router := gin.Default()
-	api.SetupRoutes(router, userHandler)
+	// Sem o logger padrão do Gin, que grava o caminho completo (com documentos);
+	// handlers.AccessLog o substitui
+	router := gin.New()
+	router.Use(gin.Recovery())
+	api.SetupRoutes(router, &cfg.Tenancy, userHandler, privacyHandler, retentionHandler, healthHandler)
 
 	// Iniciar servidor
-	serverPort := cfg.Server.Port
-	log.Printf("Server starting on %s", serverPort)
-	if err := router.Run(":" + serverPort); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{
+		Addr:         cfg.Server.GetAddress(),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	// Shutdown não espera conexões SSE terminarem sozinhas
+	srv.RegisterOnShutdown(userHandler.CloseStreams)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		log.Printf("Server failed: %v", err)
+	case <-ctx.Done():
+		log.Printf("Shutdown signal received, draining for up to %s", cfg.Server.ShutdownTimeout)
+	}
+	stop()
+
+	shutdown(srv, rabbitMQService, userRepo, &jobs, cfg.Server.ShutdownTimeout)
+}
+
+// shutdown encerra os componentes em duas fases, cada uma com sua parte do prazo.
+// Na primeira, que recebe três quartos, para de aceitar requisições e de receber
+// mensagens e aguarda os jobs em segundo plano, tudo em paralelo, até que nada mais
+// use as conexões. Na segunda fecha o MongoDB e a conexão AMQP.
+func shutdown(srv *http.Server, broker *rabbitmq.RabbitMQService, userRepo repositories.UserRepository, jobs *sync.WaitGroup, timeout time.Duration) {
+	drainTimeout := timeout * 3 / 4
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancelDrain()
+
+	var drain sync.WaitGroup
+	drain.Add(3)
+	go func() {
+		defer drain.Done()
+		if err := srv.Shutdown(drainCtx); err != nil {
+			log.Printf("Warning: HTTP server did not shut down cleanly: %v", err)
+		}
+	}()
+	go func() {
+		defer drain.Done()
+		if err := broker.StopConsuming(drainCtx); err != nil {
+			log.Printf("Warning: message consumer did not stop cleanly: %v", err)
+		}
+	}()
+	go func() {
+		defer drain.Done()
+		if err := wait(drainCtx, jobs); err != nil {
+			log.Printf("Warning: background jobs did not finish: %v", err)
+		}
+	}()
+	drain.Wait()
+
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), timeout-drainTimeout)
+	defer cancelClose()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		if userRepo != nil {
+			if err := userRepo.Close(); err != nil {
+				log.Printf("Warning: failed to close database connection: %v", err)
+			}
+		}
+		broker.Close()
+	}()
+	select {
+	case <-closed:
+	case <-closeCtx.Done():
+		log.Printf("Warning: connections did not close in time: %v", closeCtx.Err())
+		return
+	}
+
+	log.Printf("Shutdown complete")
+}
+
+// wait aguarda wg terminar ou ctx expirar
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// requireEncryptedDocuments encerra o processo se houver números de documento em
+// claro: com a criptografia ativa, as buscas por documento usam apenas o índice
+// cego, e esses registros ficariam fora da deduplicação e da eliminação (LGPD)
+func requireEncryptedDocuments(client *mongo.Client, cfg *config.DatabaseConfig, tenancy *config.TenancyConfig) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, users := range mongodb.UserCollections(client, cfg, tenancy) {
+		count, err := migrations.CountPlaintextDocuments(ctx, users)
+		if err != nil {
+			log.Fatalf("Failed to check for plaintext document numbers: %v", err)
+		}
+		if count > 0 {
+			log.Fatalf("Field encryption is enabled but %d records in %s.%s have plaintext document numbers; run \"migrate encrypt-documents\" first",
+				count, users.Database().Name(), users.Name())
+		}
+	}
+}
+
+// warnPendingMigrations avisa quando algum banco (compartilhado ou dedicado) não está
+// na versão de schema deste código
+func warnPendingMigrations(client *mongo.Client, cfg *config.DatabaseConfig, tenancy *config.TenancyConfig) {
+	runner, err := migrations.NewRunner(client, cfg, tenancy)
+	if err != nil {
+		log.Printf("Warning: failed to check schema migrations: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	current, err := runner.Current(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to check schema migrations: %v", err)
+		return
+	}
+	if current < runner.Latest() {
+		log.Printf("Warning: database schema is at version %d, run \"migrate up\" to reach version %d", current, runner.Latest())
 	}
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"api-rabbitmq/internal/domain/repositories"
+	"api-rabbitmq/internal/infrastructure/config"
+	"api-rabbitmq/internal/infrastructure/database/mongodb"
+	"api-rabbitmq/internal/infrastructure/database/mongodb/migrations"
+	"api-rabbitmq/internal/infrastructure/encryption"
+)
+
+const usage = `Usage: migrate <command> [-to version]
+
+Commands:
+  status        list migrations and whether they are applied
+  up            apply pending migrations (up to -to, default: all)
+  down          revert migrations above -to (default: only the latest)
+  encrypt-documents
+                encrypt plaintext document numbers left from before field
+                encryption was enabled (required before the API starts)
+  verify-receipts
+                check the hash chain of the erasure receipts; exits with an
+                error at the first altered, removed or reordered receipt
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	target := flags.Int("to", -1, "target schema version")
+	flags.Parse(os.Args[2:])
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := cfg.Database.Validate(); err != nil {
+		log.Fatalf("Invalid database configuration: %v", err)
+	}
+	if cfg.Database.Driver != config.DriverMongoDB {
+		log.Fatalf("Migrations only apply to the mongodb driver (REPOSITORY_DRIVER=%s)", cfg.Database.Driver)
+	}
+
+	client, err := mongodb.Connect(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	if err := cfg.Tenancy.Validate(); err != nil {
+		log.Fatalf("Invalid tenancy configuration: %v", err)
+	}
+
+	runner, err := migrations.NewRunner(client, &cfg.Database, &cfg.Tenancy)
+	if err != nil {
+		log.Fatalf("Failed to initialize migrations: %v", err)
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "status":
+		err = printStatus(ctx, runner)
+	case "up":
+		if *target < 0 {
+			*target = 0
+		}
+		err = runner.Up(ctx, *target)
+	case "encrypt-documents":
+		err = encryptDocuments(ctx, runner, cfg)
+	case "verify-receipts":
+		err = verifyReceipts(ctx, client, cfg)
+	case "down":
+		if *target < 0 {
+			current, cerr := runner.Current(ctx)
+			if cerr != nil {
+				log.Fatalf("Failed to read schema version: %v", cerr)
+			}
+			*target = max(current-1, 0)
+		}
+		err = runner.Down(ctx, *target)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("Migration %s failed: %v", command, err)
+	}
+
+	if command == "up" || command == "down" {
+		current, err := runner.Current(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read schema version: %v", err)
+		}
+		log.Printf("Schema version is now %d (latest %d)", current, runner.Latest())
+	}
+}
+
+func encryptDocuments(ctx context.Context, runner *migrations.Runner, cfg *config.Config) error {
+	cipher, err := encryption.NewFieldCipherFromConfig(&cfg.Privacy)
+	if err != nil {
+		return fmt.Errorf("invalid encryption configuration: %v", err)
+	}
+
+	encrypted, err := runner.EncryptDocuments(ctx, cipher)
+	if err != nil {
+		return err
+	}
+	log.Printf("Encrypted %d document numbers", encrypted)
+	return nil
+}
+
+func verifyReceipts(ctx context.Context, client *mongo.Client, cfg *config.Config) error {
+	receipts, err := mongodb.NewErasureReceiptRepository(client, &cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	verified, err := repositories.VerifyChain(ctx, receipts)
+	if err != nil {
+		return fmt.Errorf("%v (%d receipts verified before it)", err, verified)
+	}
+	log.Printf("Erasure receipt chain is intact (%d receipts)", verified)
+	return nil
+}
+
+func printStatus(ctx context.Context, runner *migrations.Runner) error {
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	database := ""
+	for _, s := range statuses {
+		if s.Database != database {
+			database = s.Database
+			fmt.Printf("%s:\n", database)
+		}
+		applied := "pending"
+		if s.Applied {
+			applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		reversible := ""
+		if !s.Reversible {
+			reversible = " (irreversible)"
+		}
+		fmt.Printf("%4d  %-28s  %s%s\n", s.Version, applied, s.Description, reversible)
+	}
+	return nil
+}
@@ -1,19 +1,27 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
+	neturl "net/url"
 
 	"api-rabbitmq/internal/domain/entities"
 	"api-rabbitmq/internal/infrastructure/config"
 )
 
+const defaultMaxResponseBytes = 1 << 20
+
 type ExternalServicesImpl struct {
 	httpClient *http.Client
 	config     *config.ExternalAPIsConfig
+
+	// Limiters compartilhados por todos os workers que usam esta instância
+	documentLimiter *RateLimiter
+	addressLimiter  *RateLimiter
 }
 
 func NewExternalServices(cfg *config.ExternalAPIsConfig) *ExternalServicesImpl {
@@ -21,14 +29,44 @@ func NewExternalServices(cfg *config.ExternalAPIsConfig) *ExternalServicesImpl {
 		httpClient: &http.Client{
 			Timeout: cfg.Timeout,
 		},
-		config: cfg,
+		config:          cfg,
+		documentLimiter: NewRateLimiter(cfg.DocumentValidationRateLimit, cfg.DocumentValidationBurst),
+		addressLimiter:  NewRateLimiter(cfg.AddressServiceRateLimit, cfg.AddressServiceBurst),
+	}
+}
+
+// RateLimiterStats retorna as métricas de espera de cada endpoint externo
+func (s *ExternalServicesImpl) RateLimiterStats() map[string]RateLimiterStats {
+	return map[string]RateLimiterStats{
+		"document_validation": s.documentLimiter.Stats(),
+		"address_service":     s.addressLimiter.Stats(),
 	}
 }
 
-func (s *ExternalServicesImpl) ValidateDocument(documentNumber string) (bool, error) {
+// get aguarda o limiter do endpoint e executa a requisição com o contexto informado
+func (s *ExternalServicesImpl) get(ctx context.Context, limiter *RateLimiter, url string) (*http.Response, error) {
+	if err := limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter wait aborted: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	// *url.Error inclui a URL, que contém o documento ou o CEP; só a causa é mantida
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return nil, fmt.Errorf("%s request failed: %w", urlErr.Op, urlErr.Err)
+	}
+	return resp, err
+}
+
+func (s *ExternalServicesImpl) ValidateDocument(ctx context.Context, documentNumber string) (bool, error) {
 	url := fmt.Sprintf("%s/%s", s.config.DocumentValidationURL, documentNumber)
 
-	resp, err := s.httpClient.Get(url)
+	resp, err := s.get(ctx, s.documentLimiter, url)
 	if err != nil {
 		return false, fmt.Errorf("failed to call document validation API: %v", err)
 	}
@@ -38,47 +76,114 @@ func (s *ExternalServicesImpl) ValidateDocument(documentNumber string) (bool, er
 		return false, fmt.Errorf("document validation API returned status: %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := s.readBody(resp)
 	if err != nil {
-		return false, fmt.Errorf("failed to read response body: %v", err)
+		return false, err
 	}
 
-	var validationResponse entities.DocumentValidationResponse
+	// Ponteiro para distinguir "isValid": false de um payload sem o campo
+	var validationResponse struct {
+		IsValid *bool `json:"isValid"`
+	}
 	if err := json.Unmarshal(body, &validationResponse); err != nil {
 		return false, fmt.Errorf("failed to unmarshal validation response: %v", err)
 	}
+	if validationResponse.IsValid == nil {
+		return false, fmt.Errorf("document validation response is missing isValid")
+	}
 
-	return validationResponse.IsValid, nil
+	return *validationResponse.IsValid, nil
 }
 
-func (s *ExternalServicesImpl) GetAddress(zipCode string) (*entities.AddressResponse, error) {
-	// Converter string para int para a URL
-	zipCodeInt, err := strconv.Atoi(zipCode)
-	if err != nil {
-		return nil, fmt.Errorf("invalid zip code: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/%d", s.config.AddressServiceURL, zipCodeInt)
+func (s *ExternalServicesImpl) GetAddress(ctx context.Context, zipCode entities.ZipCode) (*entities.AddressResponse, error) {
+	url := fmt.Sprintf("%s/%s", s.config.AddressServiceURL, zipCode.String())
 
-	resp, err := s.httpClient.Get(url)
+	resp, err := s.get(ctx, s.addressLimiter, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call address API: %v", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: zip code %s", entities.ErrAddressNotFound, entities.MaskZipCode(zipCode.String()))
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("address API returned status: %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := s.readBody(resp)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, err
 	}
 
 	var addressResponse entities.AddressResponse
 	if err := json.Unmarshal(body, &addressResponse); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal address response: %v", err)
 	}
+	if err := addressResponse.Validate(); err != nil {
+		return nil, fmt.Errorf("incomplete address response: %v", err)
+	}
+
+	// O endereço armazenado sempre usa o CEP normalizado, independente do formato do provedor
+	addressResponse.Zipcode = zipCode.String()
 
 	return &addressResponse, nil
 }
+
+// readBody lê o corpo da resposta limitado a MaxResponseBytes
+func (s *ExternalServicesImpl) readBody(resp *http.Response) ([]byte, error) {
+	limit := s.config.MaxResponseBytes
+	if limit <= 0 {
+		limit = defaultMaxResponseBytes
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("response body exceeds %d bytes", limit)
+	}
+	return body, nil
+}
+
+// CheckDocumentValidation verifica se a API de validação de documentos responde
+func (s *ExternalServicesImpl) CheckDocumentValidation(ctx context.Context) (any, error) {
+	return nil, s.reachable(ctx, s.config.DocumentValidationURL)
+}
+
+// CheckAddressService verifica se a API de endereços responde
+func (s *ExternalServicesImpl) CheckAddressService(ctx context.Context) (any, error) {
+	return nil, s.reachable(ctx, s.config.AddressServiceURL)
+}
+
+// CheckRateLimiters expõe o estado dos limiters de cada endpoint externo. As chamadas
+// externas não passam por circuit breaker, então não há estado de breaker a relatar.
+func (s *ExternalServicesImpl) CheckRateLimiters(ctx context.Context) (any, error) {
+	return s.RateLimiterStats(), nil
+}
+
+// reachable envia um HEAD à URL base do serviço, sem documento nem CEP e fora do
+// rate limiter. Qualquer resposta abaixo de 500 indica que o serviço está no ar,
+// já que a URL base sozinha não é um recurso válido.
+func (s *ExternalServicesImpl) reachable(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s request failed: %w", urlErr.Op, urlErr.Err)
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("service returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/infrastructure/config"
+)
+
+// newTestServices aponta os dois endpoints para um httptest.Server com handler e
+// conta as requisições recebidas
+func newTestServices(t *testing.T, maxResponseBytes int64, handler http.HandlerFunc) (*ExternalServicesImpl, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return NewExternalServices(&config.ExternalAPIsConfig{
+		DocumentValidationURL: server.URL + "/is-document-valid",
+		AddressServiceURL:     server.URL + "/address",
+		Timeout:               5 * time.Second,
+		MaxResponseBytes:      maxResponseBytes,
+	}), &calls
+}
+
+func mustZipCode(t *testing.T, raw string) entities.ZipCode {
+	t.Helper()
+	zipCode, err := entities.NewZipCode(raw)
+	if err != nil {
+		t.Fatalf("NewZipCode(%q): %v", raw, err)
+	}
+	return zipCode
+}
+
+func TestValidateDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    bool
+		wantErr string
+	}{
+		{name: "valid", status: http.StatusOK, body: `{"isValid": true}`, want: true},
+		{name: "invalid", status: http.StatusOK, body: `{"isValid": false}`, want: false},
+		{name: "missing isValid", status: http.StatusOK, body: `{"valid": true}`, wantErr: "missing isValid"},
+		{name: "malformed JSON", status: http.StatusOK, body: `{"isValid":`, wantErr: "failed to unmarshal"},
+		{name: "server error", status: http.StatusServiceUnavailable, body: `{}`, wantErr: "returned status: 503"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			s, _ := newTestServices(t, 0, func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			got, err := s.ValidateDocument(context.Background(), "12345678909")
+			if path != "/is-document-valid/12345678909" {
+				t.Errorf("request path = %q", path)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ValidateDocument error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateDocument: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ValidateDocument = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{name: "complete", status: http.StatusOK, body: `{"street":"Rua A","city":"Campinas","state":"SP","zipcode":"13086-656"}`},
+		{name: "missing street and city", status: http.StatusOK, body: `{"state":"SP"}`, wantErr: "incomplete address response"},
+		{name: "invalid state", status: http.StatusOK, body: `{"street":"Rua A","city":"Campinas","state":"São Paulo"}`, wantErr: "incomplete address response"},
+		{name: "empty object", status: http.StatusOK, body: `{}`, wantErr: "incomplete address response"},
+		{name: "malformed JSON", status: http.StatusOK, body: `[`, wantErr: "failed to unmarshal"},
+		{name: "server error", status: http.StatusBadGateway, body: ``, wantErr: "returned status: 502"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			s, _ := newTestServices(t, 0, func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			address, err := s.GetAddress(context.Background(), mustZipCode(t, "13086-656"))
+			if path != "/address/13086656" {
+				t.Errorf("request path = %q, want the normalized zip code", path)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetAddress error = %v, want %q", err, tt.wantErr)
+				}
+				if errors.Is(err, entities.ErrAddressNotFound) {
+					t.Fatal("a failed response must not be reported as address not found")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetAddress: %v", err)
+			}
+			// O CEP armazenado é o normalizado, não o formato do provedor
+			if address.Zipcode != "13086656" || address.City != "Campinas" {
+				t.Errorf("address = %+v", address)
+			}
+		})
+	}
+}
+
+func TestGetAddressNotFoundIsNotRetryable(t *testing.T) {
+	s, calls := newTestServices(t, 0, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	_, err := s.GetAddress(context.Background(), mustZipCode(t, "01310100"))
+	if !errors.Is(err, entities.ErrAddressNotFound) {
+		t.Fatalf("GetAddress error = %v, want ErrAddressNotFound", err)
+	}
+	// O CEP aparece mascarado na mensagem
+	if strings.Contains(err.Error(), "01310100") {
+		t.Errorf("error %q exposes the zip code", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("address API called %d times, want a single request", got)
+	}
+}
+
+func TestResponseBodyIsCapped(t *testing.T) {
+	const limit = 64
+	padding := strings.Repeat(" ", limit)
+
+	s, _ := newTestServices(t, limit, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/address") {
+			w.Write([]byte(`{"street":"Rua A","city":"Campinas","state":"SP"}` + padding))
+			return
+		}
+		w.Write([]byte(`{"isValid": true}` + padding))
+	})
+
+	if _, err := s.ValidateDocument(context.Background(), "12345678909"); err == nil || !strings.Contains(err.Error(), "exceeds 64 bytes") {
+		t.Errorf("ValidateDocument error = %v, want the body size cap", err)
+	}
+	if _, err := s.GetAddress(context.Background(), mustZipCode(t, "13086656")); err == nil || !strings.Contains(err.Error(), "exceeds 64 bytes") {
+		t.Errorf("GetAddress error = %v, want the body size cap", err)
+	}
+}
+
+func TestResponseBodyAtTheCapIsAccepted(t *testing.T) {
+	body := `{"isValid": true}`
+	s, _ := newTestServices(t, int64(len(body)), func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+
+	if valid, err := s.ValidateDocument(context.Background(), "12345678909"); err != nil || !valid {
+		t.Fatalf("ValidateDocument = %v, %v; want a body exactly at the cap accepted", valid, err)
+	}
+}
+
+func TestRequestErrorsDoNotExposeTheURL(t *testing.T) {
+	s, _ := newTestServices(t, 0, func(w http.ResponseWriter, r *http.Request) {})
+	s.config.DocumentValidationURL = "http://127.0.0.1:1/is-document-valid"
+
+	_, err := s.ValidateDocument(context.Background(), "12345678909")
+	if err == nil {
+		t.Fatal("ValidateDocument succeeded against a closed port")
+	}
+	if strings.Contains(err.Error(), "12345678909") {
+		t.Errorf("error %q exposes the document number", err)
+	}
+}
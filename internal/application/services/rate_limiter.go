@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiterStats métricas acumuladas de espera de um RateLimiter
+type RateLimiterStats struct {
+	Requests      int64         `json:"requests"`
+	Waited        int64         `json:"waited"`
+	TotalWait     time.Duration `json:"total_wait"`
+	MaxWait       time.Duration `json:"max_wait"`
+	Cancelled     int64         `json:"cancelled"`
+	RatePerSecond float64       `json:"rate_per_second"`
+	Burst         int           `json:"burst"`
+}
+
+// RateLimiter implementa um token bucket seguro para uso concorrente. As chamadas
+// aguardam um token em vez de falhar, respeitando o cancelamento do contexto.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	stats  RateLimiterStats
+	clock  clock
+}
+
+// clock fonte de tempo do RateLimiter, substituída nos testes
+type clock interface {
+	Now() time.Time
+	// NewTimer retorna o canal disparado após d e a função que cancela o timer
+	NewTimer(d time.Duration) (<-chan time.Time, func() bool)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	timer := time.NewTimer(d)
+	return timer.C, timer.Stop
+}
+
+// NewRateLimiter cria um limiter com rate requisições por segundo e rajada burst.
+// Um rate <= 0 desativa a limitação.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return newRateLimiter(rate, burst, systemClock{})
+}
+
+func newRateLimiter(rate float64, burst int, clock clock) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+		stats:  RateLimiterStats{RatePerSecond: rate, Burst: burst},
+		clock:  clock,
+	}
+}
+
+// Wait bloqueia até haver um token disponível ou o contexto ser cancelado
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.rate <= 0 {
+		return ctx.Err()
+	}
+
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	fired, stop := l.clock.NewTimer(delay)
+	defer stop()
+
+	select {
+	case <-fired:
+		l.recordWait(delay)
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve consome um token (possivelmente negativo) e retorna quanto tempo é preciso esperar
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	l.stats.Requests++
+
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+func (l *RateLimiter) recordWait(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stats.Waited++
+	l.stats.TotalWait += d
+	if d > l.stats.MaxWait {
+		l.stats.MaxWait = d
+	}
+}
+
+// cancel devolve o token reservado por uma espera que não chegou a acontecer
+func (l *RateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = math.Min(l.burst, l.tokens+1)
+	l.stats.Cancelled++
+}
+
+// Stats retorna uma cópia das métricas acumuladas
+func (l *RateLimiter) Stats() RateLimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
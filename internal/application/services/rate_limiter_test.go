@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock só avança com Advance; cada timer criado é anunciado em started
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []fakeTimer
+	started chan time.Duration
+}
+
+type fakeTimer struct {
+	deadline time.Time
+	fired    chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0), started: make(chan time.Duration, 16)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	c.mu.Lock()
+	timer := fakeTimer{deadline: c.now.Add(d), fired: make(chan time.Time, 1)}
+	c.timers = append(c.timers, timer)
+	c.mu.Unlock()
+
+	c.started <- d
+	return timer.fired, func() bool { return true }
+}
+
+// Advance move o relógio e dispara os timers vencidos
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.deadline.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.fired <- c.now
+	}
+	c.timers = pending
+}
+
+// waitTimer aguarda um Wait bloquear e retorna a espera calculada
+func (c *fakeClock) waitTimer(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.started:
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not block on a timer")
+		return 0
+	}
+}
+
+func waitAsync(ctx context.Context, limiter *RateLimiter) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- limiter.Wait(ctx) }()
+	return done
+}
+
+func receive(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return")
+		return nil
+	}
+}
+
+func TestRateLimiterWaitsForTokensBeyondBurst(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newRateLimiter(2, 2, clock)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Wait %d within burst: %v", i, err)
+		}
+	}
+
+	done := waitAsync(ctx, limiter)
+	if delay := clock.waitTimer(t); delay != 500*time.Millisecond {
+		t.Fatalf("delay = %v, want 500ms at 2 requests per second", delay)
+	}
+	select {
+	case <-done:
+		t.Fatal("Wait returned before the clock advanced")
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if err := receive(t, done); err != nil {
+		t.Fatalf("Wait after the delay: %v", err)
+	}
+
+	stats := limiter.Stats()
+	if stats.Requests != 3 || stats.Waited != 1 || stats.Cancelled != 0 {
+		t.Errorf("stats = %+v, want 3 requests and 1 wait", stats)
+	}
+	if stats.TotalWait != 500*time.Millisecond || stats.MaxWait != 500*time.Millisecond {
+		t.Errorf("TotalWait = %v, MaxWait = %v, want 500ms", stats.TotalWait, stats.MaxWait)
+	}
+}
+
+func TestRateLimiterRefillsWithElapsedTime(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newRateLimiter(1, 1, clock)
+	ctx := context.Background()
+
+	if err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+	clock.Advance(time.Second)
+	if err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("Wait after refill: %v", err)
+	}
+
+	// Tempo parado não acumula além da rajada: só o primeiro Wait passa direto
+	clock.Advance(time.Hour)
+	if err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("Wait within burst: %v", err)
+	}
+	done := waitAsync(ctx, limiter)
+	if delay := clock.waitTimer(t); delay != time.Second {
+		t.Fatalf("delay = %v, want 1s", delay)
+	}
+	clock.Advance(time.Second)
+	if err := receive(t, done); err != nil {
+		t.Fatalf("Wait beyond burst: %v", err)
+	}
+	if stats := limiter.Stats(); stats.Waited != 1 || stats.MaxWait != time.Second {
+		t.Errorf("stats = %+v, want a single 1s wait", stats)
+	}
+}
+
+func TestRateLimiterCancelReturnsTheToken(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newRateLimiter(1, 1, clock)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := waitAsync(ctx, limiter)
+	clock.waitTimer(t)
+	cancel()
+	if err := receive(t, done); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Wait = %v, want context.Canceled", err)
+	}
+
+	// O token reservado pela espera cancelada volta ao bucket: após 1s há um token
+	clock.Advance(time.Second)
+	if err := receive(t, waitAsync(context.Background(), limiter)); err != nil {
+		t.Fatalf("Wait after refill: %v", err)
+	}
+	select {
+	case d := <-clock.started:
+		t.Fatalf("Wait blocked for %v, the cancelled reservation was not returned", d)
+	default:
+	}
+
+	stats := limiter.Stats()
+	if stats.Cancelled != 1 || stats.Waited != 0 || stats.TotalWait != 0 {
+		t.Errorf("stats = %+v, want 1 cancellation and no completed wait", stats)
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := newRateLimiter(0, 1, newFakeClock())
+	for i := 0; i < 100; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait %d: %v", i, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestRateLimitersAreIsolatedPerEndpoint(t *testing.T) {
+	documentClock, addressClock := newFakeClock(), newFakeClock()
+	s := &ExternalServicesImpl{
+		documentLimiter: newRateLimiter(1, 1, documentClock),
+		addressLimiter:  newRateLimiter(1, 1, addressClock),
+	}
+	ctx := context.Background()
+
+	if err := s.documentLimiter.Wait(ctx); err != nil {
+		t.Fatalf("document Wait: %v", err)
+	}
+	done := waitAsync(ctx, s.documentLimiter)
+	documentClock.waitTimer(t)
+
+	// Com o limiter de documentos esgotado, o de endereços segue com o token dele
+	if err := receive(t, waitAsync(ctx, s.addressLimiter)); err != nil {
+		t.Fatalf("address Wait: %v", err)
+	}
+
+	documentClock.Advance(time.Second)
+	if err := receive(t, done); err != nil {
+		t.Fatalf("document Wait after the delay: %v", err)
+	}
+
+	stats := s.RateLimiterStats()
+	if document := stats["document_validation"]; document.Requests != 2 || document.Waited != 1 {
+		t.Errorf("document_validation stats = %+v, want 2 requests and 1 wait", document)
+	}
+	if address := stats["address_service"]; address.Requests != 1 || address.Waited != 0 {
+		t.Errorf("address_service stats = %+v, want 1 request and no wait", address)
+	}
+}
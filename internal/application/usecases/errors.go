@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+
+	"api-rabbitmq/internal/domain/entities"
+)
+
+// ErrorKind classifica os erros retornados pelos casos de uso
+type ErrorKind string
+
+const (
+	KindNotFound              ErrorKind = "not_found"
+	KindValidation            ErrorKind = "validation"
+	KindDependencyUnavailable ErrorKind = "dependency_unavailable"
+	KindConflict              ErrorKind = "conflict"
+	KindPreconditionFailed    ErrorKind = "precondition_failed"
+)
+
+// Sentinelas para comparação com errors.Is
+var (
+	ErrNotFound              = &Error{Kind: KindNotFound}
+	ErrValidation            = &Error{Kind: KindValidation}
+	ErrDependencyUnavailable = &Error{Kind: KindDependencyUnavailable}
+	ErrConflict              = &Error{Kind: KindConflict}
+	ErrPreconditionFailed    = &Error{Kind: KindPreconditionFailed}
+)
+
+// Error é o erro tipado dos casos de uso. Code e Message são estáveis e seguros
+// para expor ao cliente; Err guarda a causa interna, que nunca deve ser exposta.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  entities.ValidationErrors
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is considera iguais erros do mesmo tipo, permitindo errors.Is(err, ErrNotFound)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Kind == t.Kind && (t.Code == "" || e.Code == t.Code)
+}
+
+// NewNotFoundError cria um erro de recurso inexistente
+func NewNotFoundError(code, message string, cause error) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message, Err: cause}
+}
+
+// NewValidationError cria um erro de validação, opcionalmente com as violações por campo
+func NewValidationError(code, message string, cause error) *Error {
+	e := &Error{Kind: KindValidation, Code: code, Message: message, Err: cause}
+	var fields entities.ValidationErrors
+	if errors.As(cause, &fields) {
+		e.Fields = fields
+	}
+	return e
+}
+
+// NewDependencyUnavailableError cria um erro para falhas de banco, fila ou APIs externas
+func NewDependencyUnavailableError(code, message string, cause error) *Error {
+	return &Error{Kind: KindDependencyUnavailable, Code: code, Message: message, Err: cause}
+}
+
+// NewConflictError cria um erro de conflito com o estado atual do recurso
+func NewConflictError(code, message string, cause error) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message, Err: cause}
+}
+
+// NewPreconditionFailedError cria um erro para versões divergentes (concorrência otimista)
+func NewPreconditionFailedError(code, message string, cause error) *Error {
+	return &Error{Kind: KindPreconditionFailed, Code: code, Message: message, Err: cause}
+}
@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+// PrivacyUseCase define os casos de uso de direitos do titular (LGPD)
+type PrivacyUseCase interface {
+	EraseSubject(ctx context.Context, documentNumber string) (*entities.ErasureReceipt, error)
+}
+
+type privacyUseCase struct {
+	targets        []repositories.ErasureTarget
+	receipts       repositories.ErasureReceiptRepository
+	mode           repositories.ErasureMode
+	subjectHashKey []byte
+}
+
+// NewPrivacyUseCase cria o caso de uso de eliminação. targets deve incluir todos os
+// armazenamentos que guardam dados pessoais.
+func NewPrivacyUseCase(receipts repositories.ErasureReceiptRepository, mode repositories.ErasureMode, subjectHashKey string, targets ...repositories.ErasureTarget) PrivacyUseCase {
+	return &privacyUseCase{
+		targets:        targets,
+		receipts:       receipts,
+		mode:           mode,
+		subjectHashKey: []byte(subjectHashKey),
+	}
+}
+
+// EraseSubject elimina os dados do titular em todos os armazenamentos e grava o
+// recibo. A operação é idempotente: se algum armazenamento falhar, nenhum recibo é
+// gravado e a requisição pode ser repetida.
+func (uc *privacyUseCase) EraseSubject(ctx context.Context, documentNumber string) (*entities.ErasureReceipt, error) {
+	if err := entities.ValidateDocumentNumber(documentNumber); err != nil {
+		return nil, NewValidationError("invalid_document_number", "document number is invalid", err)
+	}
+	if uc.receipts == nil || len(uc.targets) == 0 {
+		return nil, errRepositoryUnavailable
+	}
+
+	// A eliminação alcança apenas os dados do tenant de ctx; o recibo registra qual
+	tenant, _ := entities.TenantFromContext(ctx)
+	document := entities.NormalizeDocumentNumber(documentNumber)
+	receipt := &entities.ErasureReceipt{
+		TenantID:    tenant,
+		SubjectHash: entities.SubjectHash(uc.subjectHashKey, document),
+		Mode:        string(uc.mode),
+		// O MongoDB grava datas em milissegundos; o hash precisa ser reproduzível após a leitura
+		ErasedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	var failed []string
+	for _, target := range uc.targets {
+		affected, err := target.EraseSubject(ctx, document, uc.mode)
+		if err != nil {
+			log.Printf("Erasure failed on %s for subject %s: %v", target.Name(), receipt.SubjectHash, err)
+			failed = append(failed, target.Name())
+			continue
+		}
+		receipt.Results = append(receipt.Results, entities.ErasureTargetResult{Target: target.Name(), Affected: affected})
+	}
+	if len(failed) > 0 {
+		return nil, NewDependencyUnavailableError("erasure_incomplete", "personal data could not be erased from every store, retry the request",
+			fmt.Errorf("erasure failed on targets %v", failed))
+	}
+
+	if err := uc.receipts.Append(ctx, receipt); err != nil {
+		return nil, NewDependencyUnavailableError("erasure_receipt_failed", "personal data was erased but the receipt could not be stored, retry the request", err)
+	}
+
+	log.Printf("Erased personal data for subject %s (receipt %d)", receipt.SubjectHash, receipt.Sequence)
+	return receipt, nil
+}
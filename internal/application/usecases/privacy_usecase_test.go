@@ -0,0 +1,90 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"api-rabbitmq/internal/application/usecases"
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+	"api-rabbitmq/internal/infrastructure/config"
+	"api-rabbitmq/internal/infrastructure/database/inmemory"
+)
+
+// bsonReceipts guarda os recibos serializados em BSON, como o MongoDB, para que
+// a verificação leia datas com a mesma precisão do banco
+type bsonReceipts struct {
+	chain repositories.ErasureReceiptRepository
+	docs  [][]byte
+}
+
+func (r *bsonReceipts) Append(ctx context.Context, receipt *entities.ErasureReceipt) error {
+	if err := r.chain.Append(ctx, receipt); err != nil {
+		return err
+	}
+	doc, err := bson.Marshal(receipt)
+	if err != nil {
+		return err
+	}
+	r.docs = append(r.docs, doc)
+	return nil
+}
+
+func (r *bsonReceipts) ForEach(ctx context.Context, fn func(receipt *entities.ErasureReceipt) error) error {
+	for _, doc := range r.docs {
+		var receipt entities.ErasureReceipt
+		if err := bson.Unmarshal(doc, &receipt); err != nil {
+			return err
+		}
+		if err := fn(&receipt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestEraseSubjectReceiptChainVerifiesAfterStorage(t *testing.T) {
+	ctx := entities.WithTenant(context.Background(), entities.DefaultTenant)
+
+	users, err := inmemory.NewUserRepository(&config.DatabaseConfig{CollectionName: "processed_users", DedupePolicy: string(repositories.DedupeUpsert)}, inmemory.UserRepositoryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipts := &bsonReceipts{chain: inmemory.NewErasureReceiptRepository()}
+	privacy := usecases.NewPrivacyUseCase(receipts, repositories.ErasureDelete, "subject-key", users)
+
+	for _, document := range []string{"52998224725", "11144477735", "52998224725"} {
+		if _, err := privacy.EraseSubject(ctx, document); err != nil {
+			t.Fatalf("EraseSubject(%s): %v", document, err)
+		}
+	}
+
+	verified, err := repositories.VerifyChain(ctx, receipts)
+	if err != nil {
+		t.Fatalf("VerifyChain: %v", err)
+	}
+	if verified != 3 {
+		t.Fatalf("verified %d receipts, want 3", verified)
+	}
+
+	// Alterar um recibo armazenado deve quebrar a cadeia
+	var tampered entities.ErasureReceipt
+	if err := bson.Unmarshal(receipts.docs[1], &tampered); err != nil {
+		t.Fatal(err)
+	}
+	tampered.Results = nil
+	if receipts.docs[1], err = bson.Marshal(tampered); err != nil {
+		t.Fatal(err)
+	}
+
+	verified, err = repositories.VerifyChain(ctx, receipts)
+	if !errors.Is(err, repositories.ErrReceiptChainBroken) {
+		t.Fatalf("VerifyChain after tampering: got %v, want ErrReceiptChainBroken", err)
+	}
+	if verified != 1 {
+		t.Fatalf("verified %d receipts before the break, want 1", verified)
+	}
+}
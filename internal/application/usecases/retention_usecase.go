@@ -0,0 +1,243 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+const (
+	// retentionDeleteBatch quantidade de registros removidos por operação
+	retentionDeleteBatch = 500
+	// retentionArchivePart registros por arquivo. Cada parte é concluída antes de seus
+	// registros serem removidos, o que limita a memória do job e o que uma falha
+	// deixa para trás.
+	retentionArchivePart = 10000
+)
+
+// RetentionMode como os registros expirados são removidos
+type RetentionMode string
+
+const (
+	// RetentionTTL o MongoDB remove os registros pelo índice TTL; o job apenas reporta
+	RetentionTTL RetentionMode = "ttl"
+	// RetentionArchive o job arquiva os registros expirados e depois os remove
+	RetentionArchive RetentionMode = "archive"
+)
+
+// ArchiveWriter destino de um arquivamento em andamento
+type ArchiveWriter interface {
+	Write(user *entities.ProcessedUser) error
+	// Close conclui o arquivo e retorna sua localização
+	Close() (string, error)
+	// Abort descarta o arquivo incompleto
+	Abort()
+}
+
+// Archiver cria destinos de arquivamento
+type Archiver interface {
+	Create(name string) (ArchiveWriter, error)
+}
+
+// RetentionReport resultado (ou simulação) de uma execução da política de retenção
+type RetentionReport struct {
+	Mode     RetentionMode `json:"mode"`
+	DryRun   bool          `json:"dry_run"`
+	Cutoff   time.Time     `json:"cutoff"`
+	Expired  int64         `json:"expired"`
+	Oldest   *time.Time    `json:"oldest,omitempty"`
+	Newest   *time.Time    `json:"newest,omitempty"`
+	Archives []string      `json:"archives,omitempty"`
+	Deleted  int64         `json:"deleted"`
+	Duration string        `json:"duration"`
+}
+
+// RetentionUseCase aplica a política de retenção dos usuários processados
+type RetentionUseCase interface {
+	// Report simula a execução e informa o que seria removido
+	Report(ctx context.Context) (*RetentionReport, error)
+	// Run arquiva e remove os registros expirados (somente no modo archive)
+	Run(ctx context.Context) (*RetentionReport, error)
+}
+
+type retentionUseCase struct {
+	userRepo repositories.UserRepository
+	archiver Archiver
+	mode     RetentionMode
+	period   time.Duration
+}
+
+func NewRetentionUseCase(userRepo repositories.UserRepository, archiver Archiver, mode RetentionMode, period time.Duration) RetentionUseCase {
+	return &retentionUseCase{
+		userRepo: userRepo,
+		archiver: archiver,
+		mode:     mode,
+		period:   period,
+	}
+}
+
+func (uc *retentionUseCase) Report(ctx context.Context) (*RetentionReport, error) {
+	return uc.execute(ctx, true)
+}
+
+func (uc *retentionUseCase) Run(ctx context.Context) (*RetentionReport, error) {
+	// No modo TTL quem remove é o MongoDB; executar aqui seria redundante
+	return uc.execute(ctx, uc.mode == RetentionTTL)
+}
+
+func (uc *retentionUseCase) execute(ctx context.Context, dryRun bool) (*RetentionReport, error) {
+	if uc.userRepo == nil {
+		return nil, errRepositoryUnavailable
+	}
+	if uc.period <= 0 {
+		return nil, NewValidationError("retention_disabled", "retention policy is not enabled", nil)
+	}
+
+	started := time.Now()
+	report := &RetentionReport{
+		Mode:   uc.mode,
+		DryRun: dryRun,
+		Cutoff: started.Add(-uc.period),
+	}
+	// Mais antigos primeiro: uma execução interrompida deixa um arquivo coerente
+	query := repositories.UserQuery{
+		CreatedTo: report.Cutoff,
+		SortBy:    repositories.SortByCreatedAt,
+		SortOrder: repositories.SortAscending,
+	}
+
+	part := &retentionPart{uc: uc, report: report}
+	err := uc.userRepo.ForEach(ctx, query, func(user *entities.ProcessedUser) error {
+		report.Expired++
+		createdAt := user.CreatedAt
+		if report.Oldest == nil {
+			report.Oldest = &createdAt
+		}
+		report.Newest = &createdAt
+
+		if dryRun {
+			return nil
+		}
+		return part.add(ctx, user)
+	})
+	if err == nil {
+		err = part.flush(ctx)
+	}
+	if err != nil {
+		part.abort()
+		var ucErr *Error
+		if !errors.As(err, &ucErr) {
+			err = NewDependencyUnavailableError("retention_failed", "failed to read expired users", err)
+		}
+		// As partes já concluídas foram removidas: o relatório parcial continua válido
+		return report, err
+	}
+
+	report.Duration = time.Since(started).String()
+	return report, nil
+}
+
+// retentionPart arquivo em gravação e as versões arquivadas nele, removidas quando
+// ele é concluído
+type retentionPart struct {
+	uc       *retentionUseCase
+	report   *RetentionReport
+	writer   ArchiveWriter
+	archived []repositories.UserVersion
+}
+
+func (p *retentionPart) add(ctx context.Context, user *entities.ProcessedUser) error {
+	// O arquivo só é criado quando há algo a arquivar
+	if p.writer == nil {
+		writer, err := p.uc.archiver.Create(archiveName(ctx))
+		if err != nil {
+			return NewDependencyUnavailableError("archive_unavailable", "failed to write retention archive", err)
+		}
+		p.writer = writer
+	}
+	if err := p.writer.Write(user); err != nil {
+		return NewDependencyUnavailableError("archive_unavailable", "failed to write retention archive", err)
+	}
+
+	p.archived = append(p.archived, repositories.UserVersion{ID: user.ID.Hex(), Version: user.Version})
+	if len(p.archived) >= retentionArchivePart {
+		return p.flush(ctx)
+	}
+	return nil
+}
+
+// flush conclui o arquivo e só então remove os registros arquivados. A remoção exige
+// a versão arquivada: um registro alterado depois da leitura fica para a próxima
+// execução, em vez de sumir sem estar no arquivo.
+func (p *retentionPart) flush(ctx context.Context) error {
+	if p.writer == nil {
+		return nil
+	}
+	path, err := p.writer.Close()
+	p.writer = nil
+	if err != nil {
+		return NewDependencyUnavailableError("archive_unavailable", "failed to write retention archive", err)
+	}
+	p.report.Archives = append(p.report.Archives, path)
+
+	archived := p.archived
+	p.archived = p.archived[:0]
+	for start := 0; start < len(archived); start += retentionDeleteBatch {
+		end := min(start+retentionDeleteBatch, len(archived))
+		deleted, err := p.uc.userRepo.DeleteVersions(ctx, archived[start:end])
+		p.report.Deleted += deleted
+		if err != nil {
+			return NewDependencyUnavailableError("retention_failed", "failed to delete archived users", err)
+		}
+	}
+	return nil
+}
+
+// abort descarta o arquivo incompleto; nenhum registro dele foi removido
+func (p *retentionPart) abort() {
+	if p.writer != nil {
+		p.writer.Abort()
+		p.writer = nil
+	}
+}
+
+// archiveName separa os arquivos de cada tenant
+func archiveName(ctx context.Context) string {
+	tenant, _ := entities.TenantFromContext(ctx)
+	return "processed_users-" + tenant
+}
+
+// StartRetentionJob executa Run para cada um dos tenants a cada interval até ctx ser cancelado
+func StartRetentionJob(ctx context.Context, uc RetentionUseCase, interval time.Duration, tenants []string) {
+	actor := entities.AuditActor{Type: entities.ActorSystem, ID: "retention"}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, tenant := range tenants {
+				runCtx := entities.WithAudit(ctx, actor, entities.NewCorrelationID())
+				runCtx = entities.WithTenant(runCtx, tenant)
+				report, err := uc.Run(runCtx)
+				if err != nil {
+					deleted := int64(0)
+					if report != nil {
+						deleted = report.Deleted
+					}
+					log.Printf("Retention job failed for tenant %s after deleting %d records: %v", tenant, deleted, err)
+					continue
+				}
+				log.Printf("Retention job for tenant %s: %d expired, %d deleted, archives=%q (%s)",
+					tenant, report.Expired, report.Deleted, report.Archives, report.Duration)
+			}
+		}
+	}
+}
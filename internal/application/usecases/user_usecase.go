@@ -2,7 +2,7 @@ package usecases
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
 
 	"api-rabbitmq/internal/domain/entities"
@@ -11,65 +11,289 @@ import (
 
 // UserUseCase define os casos de uso para usuários
 type UserUseCase interface {
-	ProcessUser(ctx context.Context, userData entities.UserData) (*entities.ProcessedUser, error)
-	GetProcessedUsers(ctx context.Context) ([]entities.ProcessedUser, error)
+	ProcessUser(ctx context.Context, userData entities.UserData) (*ProcessResult, error)
+	ListProcessedUsers(ctx context.Context, query repositories.UserQuery) (*repositories.UserPage, error)
+	GetProcessedUser(ctx context.Context, id string) (*entities.ProcessedUser, error)
+	// ExportProcessedUsers percorre todos os usuários que atendem aos filtros, sem paginação
+	ExportProcessedUsers(ctx context.Context, query repositories.UserQuery, fn func(user *entities.ProcessedUser) error) error
+	SearchProcessedUsers(ctx context.Context, query repositories.SearchQuery) (*repositories.UserPage, error)
+	GetStats(ctx context.Context, query repositories.StatsQuery) (*repositories.UserStats, error)
+	WatchProcessedUsers(ctx context.Context, query repositories.WatchQuery) (repositories.UserChangeStream, error)
+	UpdateProcessedUser(ctx context.Context, id string, update entities.ProcessedUserUpdate, expectedVersion int64) (*entities.ProcessedUser, error)
+	PatchProcessedUser(ctx context.Context, id string, patch entities.ProcessedUserPatch, expectedVersion int64) (*entities.ProcessedUser, error)
+	DeleteProcessedUser(ctx context.Context, id string, expectedVersion int64) error
+	// GetProcessedUserHistory retorna a trilha de auditoria do usuário, inclusive após a remoção
+	GetProcessedUserHistory(ctx context.Context, id string, limit int) ([]entities.AuditEvent, error)
+}
+
+// SaveOutcome indica o que aconteceu com o registro ao processar um usuário
+type SaveOutcome string
+
+const (
+	OutcomeCreated SaveOutcome = "created"
+	OutcomeUpdated SaveOutcome = "updated"
+	// OutcomeNotStored quando não há repositório configurado
+	OutcomeNotStored SaveOutcome = "not_stored"
+)
+
+// ProcessResult resultado de ProcessUser
+type ProcessResult struct {
+	User    *entities.ProcessedUser
+	Outcome SaveOutcome
 }
 
 type userUseCase struct {
 	userRepo    repositories.UserRepository
+	auditRepo   repositories.AuditRepository
 	extServices ExternalServices
 }
 
 // ExternalServices define as dependências externas
 type ExternalServices interface {
-	ValidateDocument(documentNumber string) (bool, error)
-	GetAddress(zipCode string) (*entities.AddressResponse, error)
+	ValidateDocument(ctx context.Context, documentNumber string) (bool, error)
+	GetAddress(ctx context.Context, zipCode entities.ZipCode) (*entities.AddressResponse, error)
 }
 
-func NewUserUseCase(userRepo repositories.UserRepository, extServices ExternalServices) UserUseCase {
+var errRepositoryUnavailable = NewDependencyUnavailableError("repository_unavailable", "user repository is not available", nil)
+
+func NewUserUseCase(userRepo repositories.UserRepository, auditRepo repositories.AuditRepository, extServices ExternalServices) UserUseCase {
 	return &userUseCase{
 		userRepo:    userRepo,
+		auditRepo:   auditRepo,
 		extServices: extServices,
 	}
 }
 
-func (uc *userUseCase) ProcessUser(ctx context.Context, userData entities.UserData) (*entities.ProcessedUser, error) {
-	log.Printf("Processing user: %s, document: %s", userData.Name, userData.DocumentNumber)
+func (uc *userUseCase) ProcessUser(ctx context.Context, userData entities.UserData) (*ProcessResult, error) {
+	log.Printf("Processing user: %s, document: %s", entities.MaskName(userData.Name), entities.MaskDocument(userData.DocumentNumber))
+
+	zipCode, err := entities.NewZipCode(userData.ZipCode)
+	if err != nil {
+		return nil, NewValidationError("invalid_zip_code", "zip code is invalid", err)
+	}
 
 	// Validar documento
-	isValid, err := uc.extServices.ValidateDocument(userData.DocumentNumber)
+	isValid, err := uc.extServices.ValidateDocument(ctx, userData.DocumentNumber)
 	if err != nil {
-		return nil, fmt.Errorf("document validation failed: %v", err)
+		return nil, NewDependencyUnavailableError("document_validation_unavailable", "document validation service is unavailable", err)
 	}
 
 	// Buscar endereço
-	address, err := uc.extServices.GetAddress(userData.ZipCode)
+	address, err := uc.extServices.GetAddress(ctx, zipCode)
+	if errors.Is(err, entities.ErrAddressNotFound) {
+		return nil, NewNotFoundError("address_not_found", "no address found for zip code", err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("address lookup failed: %v", err)
+		return nil, NewDependencyUnavailableError("address_service_unavailable", "address service is unavailable", err)
 	}
 
 	processedUser := &entities.ProcessedUser{
-		Name:     userData.Name,
-		Document: entities.DocumentUserProcessed{DocumentNumber: userData.DocumentNumber, IsValid: isValid},
-		Address:  *address,
-		Status:   "processed",
-		Message:  "User processed successfully",
+		Name: userData.Name,
+		Document: entities.DocumentUserProcessed{
+			DocumentNumber: entities.NormalizeDocumentNumber(userData.DocumentNumber),
+			IsValid:        isValid,
+		},
+		Address: *address,
+		Status:  "processed",
+		Message: "User processed successfully",
 	}
 
 	// Salvar no repositório
-	if uc.userRepo != nil {
-		_, err := uc.userRepo.Save(ctx, processedUser)
-		if err != nil {
-			log.Printf("Warning: failed to save user: %v", err)
-		}
+	if uc.userRepo == nil {
+		log.Printf("Warning: user repository not available, user was not stored")
+		return &ProcessResult{User: processedUser, Outcome: OutcomeNotStored}, nil
+	}
+
+	result, err := uc.userRepo.Save(ctx, processedUser)
+	if errors.Is(err, repositories.ErrDuplicate) {
+		return nil, NewConflictError("duplicate_document", "a user with this document number already exists", err)
+	}
+	if err != nil {
+		return nil, NewDependencyUnavailableError("repository_error", "failed to save processed user", err)
+	}
+
+	outcome := OutcomeUpdated
+	if result.Created {
+		outcome = OutcomeCreated
+	}
+	return &ProcessResult{User: processedUser, Outcome: outcome}, nil
+}
+
+func (uc *userUseCase) ListProcessedUsers(ctx context.Context, query repositories.UserQuery) (*repositories.UserPage, error) {
+	if uc.userRepo == nil {
+		return nil, errRepositoryUnavailable
+	}
+
+	page, err := uc.userRepo.Find(ctx, query)
+	switch {
+	case errors.Is(err, repositories.ErrInvalidCursor):
+		return nil, NewValidationError("invalid_cursor", "page cursor is invalid or does not match the requested sort", err)
+	case errors.Is(err, repositories.ErrInvalidQuery):
+		return nil, NewValidationError("invalid_query", err.Error(), err)
+	case err != nil:
+		return nil, NewDependencyUnavailableError("repository_error", "failed to read processed users", err)
+	}
+	return page, nil
+}
+
+func (uc *userUseCase) SearchProcessedUsers(ctx context.Context, query repositories.SearchQuery) (*repositories.UserPage, error) {
+	if uc.userRepo == nil {
+		return nil, errRepositoryUnavailable
+	}
+
+	page, err := uc.userRepo.Search(ctx, query)
+	switch {
+	case errors.Is(err, repositories.ErrInvalidCursor):
+		return nil, NewValidationError("invalid_cursor", "page cursor is invalid or does not match the search", err)
+	case errors.Is(err, repositories.ErrInvalidQuery):
+		return nil, NewValidationError("invalid_query", err.Error(), err)
+	case err != nil:
+		return nil, NewDependencyUnavailableError("repository_error", "failed to search processed users", err)
+	}
+	return page, nil
+}
+
+func (uc *userUseCase) ExportProcessedUsers(ctx context.Context, query repositories.UserQuery, fn func(user *entities.ProcessedUser) error) error {
+	if uc.userRepo == nil {
+		return errRepositoryUnavailable
 	}
 
-	return processedUser, nil
+	err := uc.userRepo.ForEach(ctx, query, fn)
+	switch {
+	case errors.Is(err, repositories.ErrInvalidQuery):
+		return NewValidationError("invalid_query", err.Error(), err)
+	case err != nil:
+		return NewDependencyUnavailableError("repository_error", "failed to export processed users", err)
+	}
+	return nil
 }
 
-func (uc *userUseCase) GetProcessedUsers(ctx context.Context) ([]entities.ProcessedUser, error) {
+func (uc *userUseCase) GetStats(ctx context.Context, query repositories.StatsQuery) (*repositories.UserStats, error) {
 	if uc.userRepo == nil {
-		return nil, fmt.Errorf("user repository not available")
+		return nil, errRepositoryUnavailable
+	}
+
+	stats, err := uc.userRepo.Stats(ctx, query)
+	switch {
+	case errors.Is(err, repositories.ErrInvalidQuery):
+		return nil, NewValidationError("invalid_query", err.Error(), err)
+	case err != nil:
+		return nil, NewDependencyUnavailableError("repository_error", "failed to compute user stats", err)
+	}
+	return stats, nil
+}
+
+func (uc *userUseCase) WatchProcessedUsers(ctx context.Context, query repositories.WatchQuery) (repositories.UserChangeStream, error) {
+	if uc.userRepo == nil {
+		return nil, errRepositoryUnavailable
+	}
+
+	stream, err := uc.userRepo.Watch(ctx, query)
+	if err != nil {
+		return nil, mapWatchError(err)
+	}
+	return stream, nil
+}
+
+// mapWatchError converte os erros de abertura do fluxo em erros tipados
+func mapWatchError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrInvalidResumeToken):
+		return NewValidationError("invalid_resume_token", "resume token is invalid", err)
+	case errors.Is(err, repositories.ErrResumeTokenExpired):
+		return NewValidationError("resume_token_expired", "resume token is no longer available, reconnect without it", err)
+	case errors.Is(err, repositories.ErrWatchUnsupported):
+		return NewDependencyUnavailableError("stream_unsupported", "live feed requires MongoDB running as a replica set", err)
+	}
+	return NewDependencyUnavailableError("repository_error", "failed to watch processed users", err)
+}
+
+func (uc *userUseCase) GetProcessedUser(ctx context.Context, id string) (*entities.ProcessedUser, error) {
+	if uc.userRepo == nil {
+		return nil, errRepositoryUnavailable
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, mapRepositoryError(err, "failed to read processed user")
+	}
+	return user, nil
+}
+
+func (uc *userUseCase) UpdateProcessedUser(ctx context.Context, id string, update entities.ProcessedUserUpdate, expectedVersion int64) (*entities.ProcessedUser, error) {
+	if uc.userRepo == nil {
+		return nil, errRepositoryUnavailable
+	}
+	if err := update.Validate(); err != nil {
+		return nil, NewValidationError("invalid_user_data", "user data failed validation", err)
+	}
+	update.Normalize()
+
+	user, err := uc.userRepo.Update(ctx, id, update, expectedVersion)
+	if err != nil {
+		return nil, mapRepositoryError(err, "failed to update processed user")
+	}
+	return user, nil
+}
+
+func (uc *userUseCase) PatchProcessedUser(ctx context.Context, id string, patch entities.ProcessedUserPatch, expectedVersion int64) (*entities.ProcessedUser, error) {
+	if uc.userRepo == nil {
+		return nil, errRepositoryUnavailable
+	}
+	if err := patch.Validate(); err != nil {
+		return nil, NewValidationError("invalid_user_data", "user data failed validation", err)
+	}
+	patch.Normalize()
+
+	user, err := uc.userRepo.Patch(ctx, id, patch, expectedVersion)
+	if err != nil {
+		return nil, mapRepositoryError(err, "failed to update processed user")
+	}
+	return user, nil
+}
+
+func (uc *userUseCase) DeleteProcessedUser(ctx context.Context, id string, expectedVersion int64) error {
+	if uc.userRepo == nil {
+		return errRepositoryUnavailable
+	}
+
+	if err := uc.userRepo.Delete(ctx, id, expectedVersion); err != nil {
+		return mapRepositoryError(err, "failed to delete processed user")
+	}
+	return nil
+}
+
+func (uc *userUseCase) GetProcessedUserHistory(ctx context.Context, id string, limit int) ([]entities.AuditEvent, error) {
+	if uc.auditRepo == nil {
+		return nil, NewDependencyUnavailableError("audit_unavailable", "audit trail is not available", nil)
+	}
+
+	events, err := uc.auditRepo.FindByUser(ctx, id, limit)
+	if err != nil {
+		return nil, mapRepositoryError(err, "failed to read user history")
+	}
+	if len(events) > 0 || uc.userRepo == nil {
+		return events, nil
+	}
+
+	// Sem eventos: registro anterior à auditoria (lista vazia) ou ID inexistente (404)
+	if _, err := uc.userRepo.FindByID(ctx, id); err != nil {
+		return nil, mapRepositoryError(err, "failed to read user history")
+	}
+	return events, nil
+}
+
+// mapRepositoryError converte os erros de UserRepository em erros tipados dos casos de uso
+func mapRepositoryError(err error, message string) error {
+	switch {
+	case errors.Is(err, repositories.ErrInvalidID):
+		return NewValidationError("invalid_user_id", "user ID is not a valid identifier", err)
+	case errors.Is(err, repositories.ErrNotFound):
+		return NewNotFoundError("user_not_found", "processed user not found", err)
+	case errors.Is(err, repositories.ErrVersionConflict):
+		return NewPreconditionFailedError("version_mismatch", "processed user was modified by another request", err)
+	case errors.Is(err, repositories.ErrDuplicate):
+		return NewConflictError("duplicate_document", "a user with this document number already exists", err)
 	}
-	return uc.userRepo.FindAll(ctx)
+	return NewDependencyUnavailableError("repository_error", message, err)
 }
@@ -0,0 +1,159 @@
+package entities
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditAction tipo de alteração registrada na trilha de auditoria
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	// AuditReprocess nova mensagem para um documento já armazenado
+	AuditReprocess AuditAction = "reprocess"
+	AuditDelete    AuditAction = "delete"
+)
+
+// Tipos de ator
+const (
+	ActorAPI      = "api"
+	ActorConsumer = "consumer"
+	ActorSystem   = "system"
+)
+
+// AuditActor quem originou a alteração (ex: {api, key:3f2a…}, {consumer, user_data_queue})
+type AuditActor struct {
+	Type string `json:"type" bson:"type"`
+	ID   string `json:"id" bson:"id"`
+}
+
+// FieldChange valor de um campo antes e depois da alteração
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before,omitempty" bson:"before,omitempty"`
+	After  interface{} `json:"after,omitempty" bson:"after,omitempty"`
+}
+
+// AuditEvent registro imutável de uma alteração em um usuário processado
+type AuditEvent struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID      string             `json:"-" bson:"tenant_id,omitempty"`
+	UserID        string             `json:"user_id" bson:"user_id"`
+	Action        AuditAction        `json:"action" bson:"action"`
+	Actor         AuditActor         `json:"actor" bson:"actor"`
+	CorrelationID string             `json:"correlation_id,omitempty" bson:"correlation_id,omitempty"`
+	// Version versão do registro após a alteração (0 na remoção)
+	Version    int64         `json:"version" bson:"version"`
+	Changes    []FieldChange `json:"changes,omitempty" bson:"changes,omitempty"`
+	OccurredAt time.Time     `json:"occurred_at" bson:"occurred_at"`
+	// SubjectKeys identificam os titulares do evento para a eliminação (LGPD): o do
+	// documento atual e, se a alteração trocou o documento, também o anterior.
+	// Preenchido pelo repositório.
+	SubjectKeys []string `json:"-" bson:"subject_keys,omitempty"`
+	// Redacted indica que os valores foram eliminados a pedido do titular
+	Redacted bool `json:"redacted,omitempty" bson:"redacted,omitempty"`
+}
+
+// SubjectDocuments documentos dos titulares afetados por uma alteração: o de after e,
+// quando diferente, o de before. before nil representa uma criação e after nil uma remoção.
+func SubjectDocuments(before, after *ProcessedUser) []string {
+	var documents []string
+	for _, u := range []*ProcessedUser{after, before} {
+		if u == nil {
+			continue
+		}
+		document := NormalizeDocumentNumber(u.Document.DocumentNumber)
+		if document != "" && (len(documents) == 0 || documents[0] != document) {
+			documents = append(documents, document)
+		}
+	}
+	return documents
+}
+
+// DiffProcessedUser lista os campos alterados entre duas versões do usuário.
+// before nil representa uma criação e after nil uma remoção.
+func DiffProcessedUser(before, after *ProcessedUser) []FieldChange {
+	if before == nil && after == nil {
+		return nil
+	}
+
+	var empty ProcessedUser
+	b, a := before, after
+	if b == nil {
+		b = &empty
+	}
+	if a == nil {
+		a = &empty
+	}
+
+	var changes []FieldChange
+	addString := func(field, bv, av string) {
+		if bv != av {
+			changes = append(changes, FieldChange{Field: field, Before: nilIfEmpty(bv), After: nilIfEmpty(av)})
+		}
+	}
+
+	addString("name", b.Name, a.Name)
+	addString("document.document_number", b.Document.DocumentNumber, a.Document.DocumentNumber)
+	if before == nil || after == nil || b.Document.IsValid != a.Document.IsValid {
+		change := FieldChange{Field: "document.is_valid"}
+		if before != nil {
+			change.Before = b.Document.IsValid
+		}
+		if after != nil {
+			change.After = a.Document.IsValid
+		}
+		changes = append(changes, change)
+	}
+	addString("address.street", b.Address.Street, a.Address.Street)
+	addString("address.city", b.Address.City, a.Address.City)
+	addString("address.state", b.Address.State, a.Address.State)
+	addString("address.zipcode", b.Address.Zipcode, a.Address.Zipcode)
+	addString("status", b.Status, a.Status)
+	addString("message", b.Message, a.Message)
+
+	return changes
+}
+
+func nilIfEmpty(v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+type auditContextKey struct{}
+
+// AuditContext origem das alterações feitas com um contexto
+type AuditContext struct {
+	Actor         AuditActor
+	CorrelationID string
+}
+
+// WithAudit anexa ao contexto o ator e o ID de correlação usados na trilha de auditoria
+func WithAudit(ctx context.Context, actor AuditActor, correlationID string) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, AuditContext{Actor: actor, CorrelationID: correlationID})
+}
+
+// AuditFromContext retorna a origem anexada por WithAudit; sem ela o ator é "system/unknown"
+func AuditFromContext(ctx context.Context) AuditContext {
+	if ac, ok := ctx.Value(auditContextKey{}).(AuditContext); ok {
+		return ac
+	}
+	return AuditContext{Actor: AuditActor{Type: ActorSystem, ID: "unknown"}}
+}
+
+// NewCorrelationID gera um ID de correlação aleatório
+func NewCorrelationID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return primitive.NewObjectID().Hex()
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,74 @@
+package entities
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Valores gravados no lugar dos dados pessoais de um registro anonimizado
+const (
+	ErasedPlaceholder = "[erased]"
+	ErasedStatus      = "erased"
+	ErasedMessage     = "Personal data erased on request of the data subject"
+)
+
+// ErasureTargetResult quantos registros foram apagados ou anonimizados em um armazenamento
+type ErasureTargetResult struct {
+	Target   string `json:"target" bson:"target"`
+	Affected int64  `json:"affected" bson:"affected"`
+}
+
+// ErasureReceipt comprovante de eliminação de dados de um titular (LGPD, art. 18).
+// Não contém dados pessoais: o titular é identificado apenas por SubjectHash.
+// Cada recibo encadeia o hash do anterior, de modo que alterar ou remover um
+// recibo invalida todos os seguintes.
+type ErasureReceipt struct {
+	ID           primitive.ObjectID    `json:"id,omitempty" bson:"_id,omitempty"`
+	Sequence     int64                 `json:"sequence" bson:"sequence"`
+	TenantID     string                `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	SubjectHash  string                `json:"subject_hash" bson:"subject_hash"`
+	Mode         string                `json:"mode" bson:"mode"`
+	Results      []ErasureTargetResult `json:"results" bson:"results"`
+	ErasedAt     time.Time             `json:"erased_at" bson:"erased_at"`
+	PreviousHash string                `json:"previous_hash" bson:"previous_hash"`
+	Hash         string                `json:"hash" bson:"hash"`
+}
+
+// SubjectHash identifica o titular nos recibos e arquivos de retenção sem armazenar
+// o documento: HMAC-SHA256 do número normalizado
+func SubjectHash(key []byte, documentNumber string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(NormalizeDocumentNumber(documentNumber)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ComputeHash calcula o hash SHA-256 do recibo sobre todos os campos, exceto ID e Hash.
+// TenantID vazio fica fora do payload, preservando o hash dos recibos anteriores à
+// multi-tenancy.
+func (r ErasureReceipt) ComputeHash() string {
+	payload, _ := json.Marshal(struct {
+		Sequence     int64                 `json:"sequence"`
+		TenantID     string                `json:"tenant_id,omitempty"`
+		SubjectHash  string                `json:"subject_hash"`
+		Mode         string                `json:"mode"`
+		Results      []ErasureTargetResult `json:"results"`
+		ErasedAt     string                `json:"erased_at"`
+		PreviousHash string                `json:"previous_hash"`
+	}{
+		Sequence:     r.Sequence,
+		TenantID:     r.TenantID,
+		SubjectHash:  r.SubjectHash,
+		Mode:         r.Mode,
+		Results:      r.Results,
+		ErasedAt:     r.ErasedAt.UTC().Format(time.RFC3339Nano),
+		PreviousHash: r.PreviousHash,
+	})
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,6 @@
+package entities
+
+import "errors"
+
+// ErrAddressNotFound indica que o serviço de endereços não conhece o CEP informado
+var ErrAddressNotFound = errors.New("address not found")
@@ -0,0 +1,103 @@
+package entities
+
+import (
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// piiMasking controla se os dados pessoais são mascarados em logs e respostas de eco.
+// Ativado por padrão; ver SetPIIMasking.
+var piiMasking atomic.Bool
+
+func init() {
+	piiMasking.Store(true)
+}
+
+// SetPIIMasking ativa ou desativa o mascaramento de dados pessoais
+func SetPIIMasking(enabled bool) {
+	piiMasking.Store(enabled)
+}
+
+// PIIMaskingEnabled indica se o mascaramento está ativo
+func PIIMaskingEnabled() bool {
+	return piiMasking.Load()
+}
+
+// MaskDocument mascara um número de documento mantendo apenas os dígitos centrais,
+// no formato usual de cada tipo (ex: CPF "***.***.526-**")
+func MaskDocument(document string) string {
+	if !PIIMaskingEnabled() {
+		return document
+	}
+
+	d := NormalizeDocumentNumber(document)
+	switch len(d) {
+	case 0:
+		return ""
+	case 11: // CPF
+		return "***.***." + d[6:9] + "-**"
+	case 14: // CNPJ
+		return "**.***.***/" + d[8:12] + "-**"
+	}
+	if len(d) <= 3 {
+		return strings.Repeat("*", len(d))
+	}
+	return strings.Repeat("*", len(d)-3) + d[len(d)-3:]
+}
+
+// MaskName mantém apenas a inicial de cada palavra (ex: "Roger Silva" → "R**** S****")
+func MaskName(name string) string {
+	if !PIIMaskingEnabled() {
+		return name
+	}
+
+	words := strings.Fields(name)
+	for i, w := range words {
+		first, size := utf8.DecodeRuneInString(w)
+		words[i] = string(first) + strings.Repeat("*", utf8.RuneCountInString(w[size:]))
+	}
+	return strings.Join(words, " ")
+}
+
+// MaskZipCode mantém apenas a região do CEP (ex: "13086656" → "13086-***")
+func MaskZipCode(zipCode string) string {
+	if !PIIMaskingEnabled() {
+		return zipCode
+	}
+
+	d := NormalizeDocumentNumber(zipCode)
+	if len(d) < 5 {
+		return strings.Repeat("*", len(d))
+	}
+	return d[:5] + "-" + strings.Repeat("*", len(d)-5)
+}
+
+// MaskStreet oculta o logradouro por completo
+func MaskStreet(street string) string {
+	if !PIIMaskingEnabled() || street == "" {
+		return street
+	}
+	return "***"
+}
+
+// Masked retorna uma cópia de UserData segura para logs
+func (u UserData) Masked() UserData {
+	return UserData{
+		Name:           MaskName(u.Name),
+		DocumentNumber: MaskDocument(u.DocumentNumber),
+		ZipCode:        MaskZipCode(u.ZipCode),
+	}
+}
+
+// Masked retorna uma cópia de ProcessedUser segura para logs, sem o histórico
+func (p ProcessedUser) Masked() ProcessedUser {
+	masked := p
+	masked.Name = MaskName(p.Name)
+	masked.Document.DocumentNumber = MaskDocument(p.Document.DocumentNumber)
+	masked.Document.DocumentHash = ""
+	masked.Address.Street = MaskStreet(p.Address.Street)
+	masked.Address.Zipcode = MaskZipCode(p.Address.Zipcode)
+	masked.History = nil
+	return masked
+}
@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+// accentFolding mapeia as letras acentuadas usadas em português (e variações
+// comuns em nomes estrangeiros) para a letra base
+var accentFolding = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n", "ý", "y", "ÿ", "y",
+)
+
+// FoldSearchText normaliza texto para busca: minúsculas, sem acentos, apenas letras,
+// dígitos e um espaço entre palavras (ex: "  São  JOSÉ-dos Campos" → "sao jose dos campos")
+func FoldSearchText(s string) string {
+	folded := accentFolding.Replace(strings.ToLower(s))
+	return strings.Join(strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// SearchTextFor texto pesquisável de um usuário: nome, logradouro e cidade
+func SearchTextFor(name string, address AddressResponse) string {
+	return FoldSearchText(name + " " + address.Street + " " + address.City)
+}
+
+// SearchTokensFor palavras distintas de SearchTextFor, na ordem em que aparecem
+func SearchTokensFor(name string, address AddressResponse) []string {
+	words := strings.Fields(SearchTextFor(name, address))
+	tokens := make([]string, 0, len(words))
+	seen := make(map[string]bool, len(words))
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
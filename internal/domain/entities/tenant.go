@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"context"
+	"regexp"
+)
+
+// DefaultTenant tenant de todos os registros quando a multi-tenancy está desligada.
+// Registros gravados antes da multi-tenancy, sem tenant_id, também pertencem a ele.
+const DefaultTenant = "default"
+
+// O ID compõe nomes de banco e de fila, por isso o alfabeto restrito
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ValidTenantID indica se id pode identificar um tenant
+func ValidTenantID(id string) bool {
+	return tenantIDPattern.MatchString(id)
+}
+
+type tenantContextKey struct{}
+
+// WithTenant anexa ao contexto o tenant dono das operações feitas com ele
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext retorna o tenant anexado por WithTenant. Ao contrário da
+// auditoria, não há valor padrão: os repositórios recusam operações sem tenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
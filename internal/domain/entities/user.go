@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -23,17 +24,68 @@ type AddressResponse struct {
 	Zipcode string `json:"zipcode" bson:"zipcode"`
 }
 
+// Validate rejeita respostas incompletas do serviço de endereços
+func (a AddressResponse) Validate() error {
+	var errs ValidationErrors
+	if strings.TrimSpace(a.Street) == "" {
+		errs = append(errs, FieldError{Field: "street", Message: "is required"})
+	}
+	if strings.TrimSpace(a.City) == "" {
+		errs = append(errs, FieldError{Field: "city", Message: "is required"})
+	}
+	if len(strings.TrimSpace(a.State)) != 2 {
+		errs = append(errs, FieldError{Field: "state", Message: "must be a 2-letter state code"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
 type ProcessedUser struct {
-	ID        primitive.ObjectID    `json:"id,omitempty" bson:"_id,omitempty"`
+	ID primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	// TenantID dono do registro, preenchido pelo repositório a partir do contexto
+	TenantID  string                `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
 	Name      string                `json:"name" bson:"name"`
 	Document  DocumentUserProcessed `json:"document" bson:"document"`
 	Address   AddressResponse       `json:"address" bson:"address"`
 	Status    string                `json:"status" bson:"status"`
 	Message   string                `json:"message" bson:"message"`
 	CreatedAt time.Time             `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at" bson:"updated_at"`
+	Version   int64                 `json:"version" bson:"version"`
+	History   []ProcessingRecord    `json:"history,omitempty" bson:"history,omitempty"`
+	// SchemaVersion formato do documento no banco, controlado pelas migrações
+	SchemaVersion int `json:"-" bson:"schema_version,omitempty"`
+	// SearchTokens palavras do nome, logradouro e cidade sem acentos, para busca por
+	// prefixo (ver SearchTokensFor)
+	SearchTokens []string `json:"-" bson:"search_tokens,omitempty"`
+}
+
+// ProcessingRecord registra o resultado de cada processamento de um mesmo documento
+type ProcessingRecord struct {
+	Status        string          `json:"status" bson:"status"`
+	Message       string          `json:"message" bson:"message"`
+	DocumentValid bool            `json:"document_valid" bson:"document_valid"`
+	Address       AddressResponse `json:"address" bson:"address"`
+	ProcessedAt   time.Time       `json:"processed_at" bson:"processed_at"`
+}
+
+// NewProcessingRecord registra o processamento atual de user
+func NewProcessingRecord(user *ProcessedUser, at time.Time) ProcessingRecord {
+	return ProcessingRecord{
+		Status:        user.Status,
+		Message:       user.Message,
+		DocumentValid: user.Document.IsValid,
+		Address:       user.Address,
+		ProcessedAt:   at,
+	}
 }
 
 type DocumentUserProcessed struct {
 	DocumentNumber string `json:"document_number" bson:"document_number"`
-	IsValid        bool   `json:"is_valid" bson:"is_valid"`
+	// DocumentHash índice cego do documento, preenchido pelo repositório quando a criptografia está ativa
+	DocumentHash string `json:"-" bson:"document_hash,omitempty"`
+	IsValid      bool   `json:"is_valid" bson:"is_valid"`
 }
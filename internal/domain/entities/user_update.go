@@ -0,0 +1,128 @@
+package entities
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const statusMaxLength = 50
+
+// ProcessedUserUpdate substitui todos os campos editáveis de um ProcessedUser (PUT)
+type ProcessedUserUpdate struct {
+	Name     string                `json:"name"`
+	Document DocumentUserProcessed `json:"document"`
+	Address  AddressResponse       `json:"address"`
+	Status   string                `json:"status"`
+	Message  string                `json:"message"`
+}
+
+// Validate verifica todos os campos da atualização
+func (u ProcessedUserUpdate) Validate() error {
+	var errs ValidationErrors
+	errs = appendIfInvalid(errs, validateName("name", u.Name))
+	errs = appendIfInvalid(errs, validateDocumentNumber("document.document_number", u.Document.DocumentNumber))
+	errs = append(errs, prefixed("address.", u.Address.Validate())...)
+	errs = appendIfInvalid(errs, validateZipCode("address.zipcode", u.Address.Zipcode))
+	errs = appendIfInvalid(errs, validateStatus("status", u.Status))
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Normalize grava o CEP somente com dígitos, como nos registros processados; chamar
+// depois de Validate
+func (u *ProcessedUserUpdate) Normalize() {
+	u.Address.Zipcode = normalizeZipCode(u.Address.Zipcode)
+}
+
+// DocumentPatch alteração parcial do documento
+type DocumentPatch struct {
+	DocumentNumber *string `json:"document_number"`
+	IsValid        *bool   `json:"is_valid"`
+}
+
+// ProcessedUserPatch altera apenas os campos informados (PATCH). Address, quando
+// informado, é substituído por inteiro.
+type ProcessedUserPatch struct {
+	Name     *string          `json:"name"`
+	Document *DocumentPatch   `json:"document"`
+	Address  *AddressResponse `json:"address"`
+	Status   *string          `json:"status"`
+	Message  *string          `json:"message"`
+}
+
+// IsEmpty indica que nenhum campo foi informado
+func (p ProcessedUserPatch) IsEmpty() bool {
+	return p.Name == nil &&
+		(p.Document == nil || (p.Document.DocumentNumber == nil && p.Document.IsValid == nil)) &&
+		p.Address == nil && p.Status == nil && p.Message == nil
+}
+
+// Validate verifica apenas os campos informados
+func (p ProcessedUserPatch) Validate() error {
+	var errs ValidationErrors
+	if p.IsEmpty() {
+		errs = append(errs, FieldError{Field: "body", Message: "at least one field must be provided"})
+	}
+	if p.Name != nil {
+		errs = appendIfInvalid(errs, validateName("name", *p.Name))
+	}
+	if p.Document != nil && p.Document.DocumentNumber != nil {
+		errs = appendIfInvalid(errs, validateDocumentNumber("document.document_number", *p.Document.DocumentNumber))
+	}
+	if p.Address != nil {
+		errs = append(errs, prefixed("address.", p.Address.Validate())...)
+		errs = appendIfInvalid(errs, validateZipCode("address.zipcode", p.Address.Zipcode))
+	}
+	if p.Status != nil {
+		errs = appendIfInvalid(errs, validateStatus("status", *p.Status))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Normalize grava o CEP informado somente com dígitos; chamar depois de Validate
+func (p *ProcessedUserPatch) Normalize() {
+	if p.Address != nil {
+		address := *p.Address
+		address.Zipcode = normalizeZipCode(address.Zipcode)
+		p.Address = &address
+	}
+}
+
+// normalizeZipCode forma canônica de um CEP já validado
+func normalizeZipCode(raw string) string {
+	zipCode, err := NewZipCode(raw)
+	if err != nil {
+		return raw
+	}
+	return zipCode.String()
+}
+
+func validateStatus(field, value string) *FieldError {
+	switch n := utf8.RuneCountInString(strings.TrimSpace(value)); {
+	case n == 0:
+		return &FieldError{Field: field, Message: "is required"}
+	case n > statusMaxLength:
+		return &FieldError{Field: field, Message: "must have at most 50 characters"}
+	}
+	return nil
+}
+
+// prefixed converte o erro de validação de um sub-objeto adicionando o prefixo aos campos
+func prefixed(prefix string, err error) ValidationErrors {
+	fields, ok := err.(ValidationErrors)
+	if !ok {
+		return nil
+	}
+	out := make(ValidationErrors, 0, len(fields))
+	for _, fe := range fields {
+		out = append(out, FieldError{Field: prefix + fe.Field, Message: fe.Message})
+	}
+	return out
+}
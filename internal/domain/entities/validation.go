@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	nameMinLength     = 2
+	nameMaxLength     = 100
+	documentMinDigits = 9  // RG
+	documentMaxDigits = 14 // CNPJ
+)
+
+// FieldError descreve uma violação de regra em um campo específico
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors agrupa todas as violações encontradas em uma validação
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, fe := range v {
+		msgs = append(msgs, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// NormalizeDocumentNumber remove pontuação comum (".", "-", "/", espaços) do documento
+func NormalizeDocumentNumber(document string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '-', '/', ' ':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(document))
+}
+
+// Validate verifica as regras de UserData e retorna ValidationErrors com todas as violações
+func (u UserData) Validate() error {
+	var errs ValidationErrors
+
+	errs = appendIfInvalid(errs, validateName("name", u.Name))
+	errs = appendIfInvalid(errs, validateDocumentNumber("document_number", u.DocumentNumber))
+
+	errs = appendIfInvalid(errs, validateZipCode("zipCode", u.ZipCode))
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateDocumentNumber verifica o formato de um número de documento isolado
+func ValidateDocumentNumber(document string) error {
+	if fe := validateDocumentNumber("document_number", document); fe != nil {
+		return ValidationErrors{*fe}
+	}
+	return nil
+}
+
+func validateName(field, value string) *FieldError {
+	switch n := utf8.RuneCountInString(strings.TrimSpace(value)); {
+	case n == 0:
+		return &FieldError{Field: field, Message: "is required"}
+	case n < nameMinLength || n > nameMaxLength:
+		return &FieldError{Field: field, Message: "must have between 2 and 100 characters"}
+	}
+	return nil
+}
+
+func validateDocumentNumber(field, value string) *FieldError {
+	document := NormalizeDocumentNumber(value)
+	switch {
+	case document == "":
+		return &FieldError{Field: field, Message: "is required"}
+	case strings.IndexFunc(document, func(r rune) bool { return r < '0' || r > '9' }) >= 0:
+		return &FieldError{Field: field, Message: "must contain only digits"}
+	case len(document) < documentMinDigits || len(document) > documentMaxDigits:
+		return &FieldError{Field: field, Message: "must have between 9 and 14 digits"}
+	}
+	return nil
+}
+
+func validateZipCode(field, value string) *FieldError {
+	if strings.TrimSpace(value) == "" {
+		return &FieldError{Field: field, Message: "is required"}
+	}
+	if _, err := NewZipCode(value); err != nil {
+		return &FieldError{Field: field, Message: "must be a valid 8-digit CEP"}
+	}
+	return nil
+}
+
+func appendIfInvalid(errs ValidationErrors, fe *FieldError) ValidationErrors {
+	if fe != nil {
+		errs = append(errs, *fe)
+	}
+	return errs
+}
@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUserDataValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		user UserData
+		want ValidationErrors
+	}{
+		{
+			name: "valid",
+			user: UserData{Name: "Roger", DocumentNumber: "251.478.526-0", ZipCode: "13086-656"},
+		},
+		{
+			name: "all fields missing",
+			user: UserData{Name: "  "},
+			want: ValidationErrors{
+				{Field: "name", Message: "is required"},
+				{Field: "document_number", Message: "is required"},
+				{Field: "zipCode", Message: "is required"},
+			},
+		},
+		{
+			name: "every field invalid",
+			user: UserData{Name: "R", DocumentNumber: "25147852A", ZipCode: "00999999"},
+			want: ValidationErrors{
+				{Field: "name", Message: "must have between 2 and 100 characters"},
+				{Field: "document_number", Message: "must contain only digits"},
+				{Field: "zipCode", Message: "must be a valid 8-digit CEP"},
+			},
+		},
+		{
+			name: "document and zip code invalid",
+			user: UserData{Name: "Roger", DocumentNumber: "12345678", ZipCode: "1308665"},
+			want: ValidationErrors{
+				{Field: "document_number", Message: "must have between 9 and 14 digits"},
+				{Field: "zipCode", Message: "must be a valid 8-digit CEP"},
+			},
+		},
+		{
+			name: "only the name invalid",
+			user: UserData{Name: strings.Repeat("a", 101), DocumentNumber: "12345678000195", ZipCode: "01310100"},
+			want: ValidationErrors{
+				{Field: "name", Message: "must have between 2 and 100 characters"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.user.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+
+			var got ValidationErrors
+			if !errors.As(err, &got) {
+				t.Fatalf("Validate() = %v, want ValidationErrors", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationErrorsMessageListsEveryField(t *testing.T) {
+	err := UserData{}.Validate()
+	want := "validation failed: name: is required; document_number: is required; zipCode: is required"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Error() = %v, want %q", err, want)
+	}
+}
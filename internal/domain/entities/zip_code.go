@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidZipCode indica que o CEP informado não é válido
+var ErrInvalidZipCode = errors.New("invalid zip code")
+
+const (
+	zipCodeLength = 8
+	// O menor CEP distribuído pelos Correios é 01000-000 (São Paulo)
+	minZipCode = "01000000"
+)
+
+// ZipCode representa um CEP normalizado com 8 dígitos, preservando zeros à esquerda
+type ZipCode string
+
+// NewZipCode normaliza e valida um CEP. Aceita entradas como "13086656",
+// "13086-656" ou "01.310-100".
+func NewZipCode(raw string) (ZipCode, error) {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-' || r == '.' || r == ' ':
+			// separadores aceitos
+		default:
+			return "", fmt.Errorf("%w: unexpected character %q", ErrInvalidZipCode, r)
+		}
+	}
+
+	digits := b.String()
+	if len(digits) != zipCodeLength {
+		return "", fmt.Errorf("%w: must have %d digits", ErrInvalidZipCode, zipCodeLength)
+	}
+	if digits < minZipCode {
+		return "", fmt.Errorf("%w: out of range", ErrInvalidZipCode)
+	}
+
+	return ZipCode(digits), nil
+}
+
+// String retorna o CEP somente com dígitos (ex: "01310100")
+func (z ZipCode) String() string {
+	return string(z)
+}
+
+// Formatted retorna o CEP no formato 00000-000
+func (z ZipCode) Formatted() string {
+	if len(z) != zipCodeLength {
+		return string(z)
+	}
+	return string(z[:5]) + "-" + string(z[5:])
+}
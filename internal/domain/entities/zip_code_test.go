@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewZipCode(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		want          string
+		wantFormatted string
+		wantErr       bool
+	}{
+		{name: "digits only", raw: "13086656", want: "13086656", wantFormatted: "13086-656"},
+		{name: "leading zero preserved", raw: "01310100", want: "01310100", wantFormatted: "01310-100"},
+		{name: "hyphenated", raw: "13086-656", want: "13086656", wantFormatted: "13086-656"},
+		{name: "dots and hyphen", raw: "01.310-100", want: "01310100", wantFormatted: "01310-100"},
+		{name: "surrounding spaces", raw: "  13086 656 ", want: "13086656", wantFormatted: "13086-656"},
+		{name: "lowest valid", raw: "01000-000", want: "01000000", wantFormatted: "01000-000"},
+		{name: "below range", raw: "00999-999", wantErr: true},
+		{name: "all zeros", raw: "00000000", wantErr: true},
+		{name: "leading zero lost", raw: "1310100", wantErr: true},
+		{name: "too many digits", raw: "130866560", wantErr: true},
+		{name: "letters", raw: "13086-65a", wantErr: true},
+		{name: "empty", raw: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewZipCode(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidZipCode) {
+					t.Fatalf("NewZipCode(%q) error = %v, want ErrInvalidZipCode", tt.raw, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewZipCode(%q): %v", tt.raw, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("String() = %q, want %q", got.String(), tt.want)
+			}
+			if got.Formatted() != tt.wantFormatted {
+				t.Errorf("Formatted() = %q, want %q", got.Formatted(), tt.wantFormatted)
+			}
+		})
+	}
+}
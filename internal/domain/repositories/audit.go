@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	"api-rabbitmq/internal/domain/entities"
+)
+
+const (
+	DefaultHistoryLimit = 50
+	MaxHistoryLimit     = 500
+)
+
+// AuditRepository trilha de auditoria append-only das alterações em usuários processados.
+// Guarda dados pessoais nos valores alterados, por isso também é um ErasureTarget.
+type AuditRepository interface {
+	Append(ctx context.Context, event *entities.AuditEvent) error
+	// FindByUser retorna os eventos do usuário, do mais recente para o mais antigo;
+	// ErrInvalidID para IDs mal formados
+	FindByUser(ctx context.Context, userID string, limit int) ([]entities.AuditEvent, error)
+	ErasureTarget
+}
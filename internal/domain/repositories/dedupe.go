@@ -0,0 +1,33 @@
+package repositories
+
+import "fmt"
+
+// DedupePolicy define o que fazer ao salvar um usuário cujo documento já existe
+type DedupePolicy string
+
+const (
+	// DedupeUpsert atualiza o registro existente e acumula o histórico de processamentos
+	DedupeUpsert DedupePolicy = "upsert"
+	// DedupeReject recusa o duplicado com ErrDuplicate
+	DedupeReject DedupePolicy = "reject"
+	// DedupeInsert sempre insere um novo registro (comportamento legado)
+	DedupeInsert DedupePolicy = "insert"
+)
+
+// MaxHistoryRecords limite de registros de histórico mantidos por usuário
+const MaxHistoryRecords = 50
+
+// ParseDedupePolicy converte o valor de configuração em DedupePolicy
+func ParseDedupePolicy(value string) (DedupePolicy, error) {
+	switch p := DedupePolicy(value); p {
+	case DedupeUpsert, DedupeReject, DedupeInsert:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown dedupe policy %q", value)
+}
+
+// SaveResult resultado de Save: o ID do registro e se ele foi criado ou atualizado
+type SaveResult struct {
+	ID      string
+	Created bool
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"api-rabbitmq/internal/domain/entities"
+)
+
+// ErasureMode define como os dados do titular são eliminados
+type ErasureMode string
+
+const (
+	// ErasureDelete remove os registros
+	ErasureDelete ErasureMode = "delete"
+	// ErasureAnonymize mantém os registros para estatística, sem nenhum dado pessoal
+	ErasureAnonymize ErasureMode = "anonymize"
+)
+
+// ParseErasureMode converte o valor de configuração em ErasureMode
+func ParseErasureMode(value string) (ErasureMode, error) {
+	switch m := ErasureMode(value); m {
+	case ErasureDelete, ErasureAnonymize:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown erasure mode %q", value)
+}
+
+// ErasureTarget é implementado por todo armazenamento que guarda dados pessoais
+// de um titular. Novos armazenamentos devem ser registrados no caso de uso de
+// privacidade para que a eliminação continue completa.
+type ErasureTarget interface {
+	Name() string
+	// EraseSubject elimina todos os dados do documento informado e retorna quantos registros foram afetados
+	EraseSubject(ctx context.Context, documentNumber string, mode ErasureMode) (int64, error)
+}
+
+// ErasureReceiptRepository armazena os recibos de eliminação em cadeia de hashes
+type ErasureReceiptRepository interface {
+	// Append define Sequence, PreviousHash e Hash a partir do último recibo e grava o novo
+	Append(ctx context.Context, receipt *entities.ErasureReceipt) error
+	// ForEach percorre os recibos em ordem de Sequence; um erro de fn interrompe a leitura
+	ForEach(ctx context.Context, fn func(receipt *entities.ErasureReceipt) error) error
+}
+
+// ErrReceiptChainBroken indica um recibo alterado, removido ou fora de ordem
+var ErrReceiptChainBroken = errors.New("erasure receipt chain is broken")
+
+// VerifyChain recalcula o hash de cada recibo e confere o encadeamento com o
+// anterior. Retorna quantos recibos foram verificados; em caso de falha, o erro
+// envolve ErrReceiptChainBroken e indica a primeira sequência inválida.
+func VerifyChain(ctx context.Context, receipts ErasureReceiptRepository) (int64, error) {
+	var verified int64
+	var previous entities.ErasureReceipt
+	err := receipts.ForEach(ctx, func(receipt *entities.ErasureReceipt) error {
+		switch {
+		case receipt.Sequence != previous.Sequence+1:
+			return fmt.Errorf("%w: expected sequence %d, found %d", ErrReceiptChainBroken, previous.Sequence+1, receipt.Sequence)
+		case receipt.PreviousHash != previous.Hash:
+			return fmt.Errorf("%w: receipt %d does not link to receipt %d", ErrReceiptChainBroken, receipt.Sequence, previous.Sequence)
+		case receipt.ComputeHash() != receipt.Hash:
+			return fmt.Errorf("%w: receipt %d hash does not match its contents", ErrReceiptChainBroken, receipt.Sequence)
+		}
+		previous = *receipt
+		verified++
+		return nil
+	})
+	return verified, err
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import "errors"
+
+// Erros retornados pelas implementações de UserRepository
+var (
+	ErrNotFound  = errors.New("user not found")
+	ErrInvalidID = errors.New("invalid user ID")
+	ErrDuplicate = errors.New("user with this document number already exists")
+	// ErrVersionConflict indica que o registro foi alterado desde a versão informada
+	ErrVersionConflict = errors.New("user version conflict")
+	// ErrTenantRequired indica uma operação sem tenant no contexto (ver entities.WithTenant)
+	ErrTenantRequired = errors.New("tenant is required")
+)
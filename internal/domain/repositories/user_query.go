@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Erros de consulta, ambos causados por parâmetros do cliente
+var (
+	ErrInvalidQuery  = errors.New("invalid user query")
+	ErrInvalidCursor = errors.New("invalid page cursor")
+)
+
+// SortField campos aceitos para ordenação da listagem
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByName      SortField = "name"
+)
+
+// SortOrder direção da ordenação
+type SortOrder string
+
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
+// UserQuery filtros, ordenação e paginação para a listagem de usuários processados
+type UserQuery struct {
+	Limit     int
+	Cursor    string
+	SortBy    SortField
+	SortOrder SortOrder
+
+	Status        string
+	DocumentValid *bool
+	State         string
+	City          string
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+}
+
+// UserPage uma página de resultados; NextCursor vazio indica a última página
+type UserPage struct {
+	Items      []entities.ProcessedUser
+	NextCursor string
+}
+
+// Normalize aplica valores padrão e valida a consulta
+func (q *UserQuery) Normalize() error {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageLimit
+	}
+	if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+
+	switch q.SortBy {
+	case "":
+		q.SortBy = SortByCreatedAt
+	case SortByCreatedAt, SortByName:
+	default:
+		return fmt.Errorf("%w: unsupported sort field %q", ErrInvalidQuery, q.SortBy)
+	}
+
+	switch q.SortOrder {
+	case "":
+		q.SortOrder = SortDescending
+	case SortAscending, SortDescending:
+	default:
+		return fmt.Errorf("%w: unsupported sort order %q", ErrInvalidQuery, q.SortOrder)
+	}
+
+	if !q.CreatedFrom.IsZero() && !q.CreatedTo.IsZero() && q.CreatedTo.Before(q.CreatedFrom) {
+		return fmt.Errorf("%w: created_to must not be before created_from", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// PageCursor posição da última linha entregue, codificada de forma opaca para o cliente
+type PageCursor struct {
+	SortBy    SortField `json:"s"`
+	SortOrder SortOrder `json:"o"`
+	Value     string    `json:"v"`
+	ID        string    `json:"id"`
+}
+
+// NewPageCursor cria o cursor que aponta para depois de user na ordenação de q
+func NewPageCursor(q UserQuery, user entities.ProcessedUser) PageCursor {
+	c := PageCursor{SortBy: q.SortBy, SortOrder: q.SortOrder, ID: user.ID.Hex()}
+	switch q.SortBy {
+	case SortByName:
+		c.Value = user.Name
+	default:
+		c.Value = user.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+// CreatedAt interpreta Value quando a ordenação é por created_at
+func (c PageCursor) CreatedAt() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return t, nil
+}
+
+// Encode serializa o cursor em um token opaco
+func (c PageCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor interpreta um token e garante que pertence à mesma ordenação de q
+func DecodeCursor(token string, q UserQuery) (PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return PageCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var c PageCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return PageCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if c.SortBy != q.SortBy || c.SortOrder != q.SortOrder || c.ID == "" {
+		return PageCursor{}, fmt.Errorf("%w: cursor does not match the requested sort", ErrInvalidCursor)
+	}
+	if c.SortBy == SortByCreatedAt {
+		if _, err := c.CreatedAt(); err != nil {
+			return PageCursor{}, err
+		}
+	}
+	return c, nil
+}
@@ -8,8 +8,42 @@ import (
 
 // UserRepository define a interface para operações de banco de dados
 type UserRepository interface {
-	Save(ctx context.Context, user *entities.ProcessedUser) (string, error)
+	// Save persiste o usuário conforme a DedupePolicy da implementação; ErrDuplicate quando rejeitado
+	Save(ctx context.Context, user *entities.ProcessedUser) (*SaveResult, error)
 	FindAll(ctx context.Context) ([]entities.ProcessedUser, error)
+	// Find retorna uma página filtrada e ordenada; ErrInvalidQuery/ErrInvalidCursor para parâmetros inválidos
+	Find(ctx context.Context, query UserQuery) (*UserPage, error)
+	// ForEach percorre, sem carregar tudo em memória, os usuários que atendem aos filtros
+	// e à ordenação de query (Limit e Cursor são ignorados). Um erro de fn interrompe a leitura.
+	ForEach(ctx context.Context, query UserQuery, fn func(user *entities.ProcessedUser) error) error
+	// Search busca por nome, logradouro e cidade, por relevância; ErrInvalidQuery/ErrInvalidCursor
+	// para parâmetros inválidos
+	Search(ctx context.Context, query SearchQuery) (*UserPage, error)
+	// Stats agrega contagens e volume de processamento no período; ErrInvalidQuery para períodos inválidos
+	Stats(ctx context.Context, query StatsQuery) (*UserStats, error)
+	// Watch observa as alterações a partir de agora ou de query.ResumeToken; ErrWatchUnsupported,
+	// ErrInvalidResumeToken e ErrResumeTokenExpired conforme o caso
+	Watch(ctx context.Context, query WatchQuery) (UserChangeStream, error)
+	// FindByID retorna ErrInvalidID para IDs mal formados e ErrNotFound quando não existe
 	FindByID(ctx context.Context, id string) (*entities.ProcessedUser, error)
+	// Update, Patch e Delete usam concorrência otimista: falham com ErrVersionConflict
+	// se a versão atual for diferente de expectedVersion (AnyVersion ignora a checagem)
+	Update(ctx context.Context, id string, update entities.ProcessedUserUpdate, expectedVersion int64) (*entities.ProcessedUser, error)
+	Patch(ctx context.Context, id string, patch entities.ProcessedUserPatch, expectedVersion int64) (*entities.ProcessedUser, error)
+	Delete(ctx context.Context, id string, expectedVersion int64) error
+	// DeleteVersions remove os registros que ainda estão na versão informada (uso interno,
+	// ex: retenção); os alterados ou já removidos são ignorados. Retorna quantos removeu.
+	DeleteVersions(ctx context.Context, refs []UserVersion) (int64, error)
+	// Todo repositório de usuários guarda dados pessoais e participa da eliminação (LGPD)
+	ErasureTarget
 	Close() error
 }
+
+// UserVersion identifica um registro em uma versão específica
+type UserVersion struct {
+	ID      string
+	Version int64
+}
+
+// AnyVersion desativa a checagem de versão (If-Match: *)
+const AnyVersion int64 = -1
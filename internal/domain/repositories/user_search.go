@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	searchMinLength = 2
+	searchMaxLength = 100
+)
+
+// SearchMode estratégia usada em uma busca
+type SearchMode string
+
+const (
+	// SearchFullText palavras inteiras (com radicais em português), por relevância
+	SearchFullText SearchMode = "text"
+	// SearchPartial prefixos de palavras sem acentos, por nome; usada quando a busca
+	// por texto não encontra nada (ex: "jo sil" para "João Silva")
+	SearchPartial SearchMode = "partial"
+)
+
+// SearchQuery busca em nome, logradouro e cidade
+type SearchQuery struct {
+	Text   string
+	Limit  int
+	Cursor string
+}
+
+// Normalize aplica os padrões e valida o termo; retorna ErrInvalidQuery
+func (q *SearchQuery) Normalize() error {
+	q.Text = strings.TrimSpace(q.Text)
+	if n := utf8.RuneCountInString(q.Text); n < searchMinLength || n > searchMaxLength {
+		return fmt.Errorf("%w: search text must have between %d and %d characters", ErrInvalidQuery, searchMinLength, searchMaxLength)
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageLimit
+	}
+	if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+	return nil
+}
+
+// SearchCursor posição na busca. A ordem por relevância não tem chave estável,
+// então o cursor guarda o deslocamento e o modo escolhido na primeira página.
+type SearchCursor struct {
+	Mode   SearchMode `json:"m"`
+	Text   string     `json:"q"`
+	Offset int        `json:"n"`
+}
+
+func (c SearchCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeSearchCursor interpreta um token e garante que pertence ao mesmo termo de q
+func DecodeSearchCursor(token string, q SearchQuery) (SearchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return SearchCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var c SearchCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return SearchCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if c.Text != q.Text || c.Offset <= 0 || (c.Mode != SearchFullText && c.Mode != SearchPartial) {
+		return SearchCursor{}, fmt.Errorf("%w: cursor does not match the search", ErrInvalidCursor)
+	}
+	return c, nil
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+)
+
+// StatsInterval granularidade do volume de processamento
+type StatsInterval string
+
+const (
+	IntervalHour StatsInterval = "hour"
+	IntervalDay  StatsInterval = "day"
+)
+
+// Limites de intervalo para manter o número de buckets razoável
+const (
+	MaxHourlyStatsRange = 31 * 24 * time.Hour
+	MaxDailyStatsRange  = 366 * 24 * time.Hour
+	// MaxStatsCities quantidade de cidades retornadas (as com mais usuários)
+	MaxStatsCities = 100
+)
+
+// StatsQuery período e granularidade das estatísticas. Contagens consideram os usuários
+// criados no período; o volume conta cada processamento (inclusive reprocessamentos).
+type StatsQuery struct {
+	From     time.Time
+	To       time.Time
+	Interval StatsInterval
+	// Timezone fuso (IANA) usado para delimitar horas e dias; padrão UTC
+	Timezone string
+}
+
+// Normalize aplica os padrões (últimos 7 dias por dia, ou últimas 24 horas por hora)
+// e valida o período; retorna ErrInvalidQuery
+func (q *StatsQuery) Normalize() error {
+	if q.Interval == "" {
+		q.Interval = IntervalDay
+	}
+	if q.Timezone == "" {
+		q.Timezone = "UTC"
+	}
+	if q.To.IsZero() {
+		q.To = time.Now()
+	}
+
+	maxRange := MaxDailyStatsRange
+	switch q.Interval {
+	case IntervalDay:
+		if q.From.IsZero() {
+			q.From = q.To.AddDate(0, 0, -7)
+		}
+	case IntervalHour:
+		maxRange = MaxHourlyStatsRange
+		if q.From.IsZero() {
+			q.From = q.To.Add(-24 * time.Hour)
+		}
+	default:
+		return fmt.Errorf("%w: unknown interval %q", ErrInvalidQuery, q.Interval)
+	}
+
+	if _, err := time.LoadLocation(q.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidQuery, q.Timezone)
+	}
+	if !q.From.Before(q.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidQuery)
+	}
+	if q.To.Sub(q.From) > maxRange {
+		return fmt.Errorf("%w: range is limited to %d days for interval %s",
+			ErrInvalidQuery, int(maxRange.Hours()/24), q.Interval)
+	}
+	return nil
+}
+
+// CountBucket contagem por valor de um campo
+type CountBucket struct {
+	Key   string `json:"key" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+// CityCount contagem por cidade
+type CityCount struct {
+	State string `json:"state" bson:"state"`
+	City  string `json:"city" bson:"city"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+// TimeBucket volume de processamento em um intervalo iniciado em Start
+type TimeBucket struct {
+	Start time.Time `json:"start" bson:"_id"`
+	Count int64     `json:"count" bson:"count"`
+}
+
+// DocumentStats documentos válidos e inválidos
+type DocumentStats struct {
+	Valid   int64 `json:"valid"`
+	Invalid int64 `json:"invalid"`
+}
+
+// UserStats estatísticas agregadas dos usuários processados
+type UserStats struct {
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Interval  StatsInterval `json:"interval"`
+	Timezone  string        `json:"timezone"`
+	Total     int64         `json:"total"`
+	ByStatus  []CountBucket `json:"by_status"`
+	Documents DocumentStats `json:"documents"`
+	ByState   []CountBucket `json:"by_state"`
+	ByCity    []CityCount   `json:"by_city"`
+	Volume    []TimeBucket  `json:"volume"`
+}
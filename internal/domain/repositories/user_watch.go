@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+)
+
+// Erros da observação de alterações
+var (
+	// ErrInvalidResumeToken token mal formado ou que não pertence a este fluxo
+	ErrInvalidResumeToken = errors.New("invalid resume token")
+	// ErrResumeTokenExpired o ponto de retomada já saiu do histórico do banco
+	ErrResumeTokenExpired = errors.New("resume token expired")
+	// ErrWatchUnsupported o banco não suporta observação de alterações (ex: MongoDB sem replica set)
+	ErrWatchUnsupported = errors.New("change streams are not supported by the database")
+)
+
+// ChangeType tipo da alteração observada
+type ChangeType string
+
+const (
+	ChangeInsert  ChangeType = "insert"
+	ChangeUpdate  ChangeType = "update"
+	ChangeReplace ChangeType = "replace"
+	ChangeDelete  ChangeType = "delete"
+)
+
+// UserChange uma alteração em um usuário processado. User traz o estado atual do
+// registro e é nulo nas remoções.
+type UserChange struct {
+	Type   ChangeType              `json:"type"`
+	UserID string                  `json:"user_id"`
+	User   *entities.ProcessedUser `json:"user,omitempty"`
+	At     time.Time               `json:"at"`
+	// ResumeToken permite retomar a observação logo após esta alteração
+	ResumeToken string `json:"-"`
+}
+
+// WatchQuery filtros da observação. Com Status, remoções não são entregues, pois o
+// registro removido não tem mais status. Remoções também não são entregues a tenants
+// que dividem o armazenamento com outros, pois o evento não identifica o tenant.
+type WatchQuery struct {
+	Status      string
+	ResumeToken string
+}
+
+// UserChangeStream fluxo de alterações aberto por UserRepository.Watch
+type UserChangeStream interface {
+	// Next bloqueia até a próxima alteração ou até ctx ser cancelado
+	Next(ctx context.Context) (*UserChange, error)
+	Close(ctx context.Context) error
+}
@@ -0,0 +1,164 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+// maxArchiveLine tamanho máximo de um registro arquivado
+const maxArchiveLine = 1 << 20
+
+func (a *NDJSONArchiver) Name() string {
+	return "retention_archive"
+}
+
+// EraseSubject reescreve os arquivos de retenção sem os registros do titular no tenant
+// de ctx: removidos em ErasureDelete, anonimizados em ErasureAnonymize com os mesmos
+// valores dos repositórios. Aguarda um arquivamento em andamento terminar.
+func (a *NDJSONArchiver) EraseSubject(ctx context.Context, documentNumber string, mode repositories.ErasureMode) (int64, error) {
+	tenant, ok := entities.TenantFromContext(ctx)
+	if !ok {
+		return 0, repositories.ErrTenantRequired
+	}
+	subject := entities.SubjectHash(a.subjectHashKey, documentNumber)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(a.dir, "*"+archiveExt))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list archives: %v", err)
+	}
+
+	var affected int64
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return affected, err
+		}
+		n, err := a.eraseFromArchive(path, tenant, subject, mode)
+		affected += n
+		if err != nil {
+			return affected, err
+		}
+	}
+	return affected, nil
+}
+
+// eraseFromArchive grava uma cópia do arquivo sem o titular e a renomeia sobre o
+// original; o arquivo só é reescrito se contiver o titular
+func (a *NDJSONArchiver) eraseFromArchive(path, tenant, subject string, mode repositories.ErasureMode) (affected int64, err error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open archive %s: %v", filepath.Base(path), err)
+	}
+	defer in.Close()
+
+	gzIn, err := gzip.NewReader(in)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read archive %s: %v", filepath.Base(path), err)
+	}
+	defer gzIn.Close()
+
+	tmp := path + ".erasing"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rewrite archive %s: %v", filepath.Base(path), err)
+	}
+	defer func() {
+		if err != nil || affected == 0 {
+			out.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	gzOut := gzip.NewWriter(out)
+	buf := bufio.NewWriter(gzOut)
+
+	scanner := bufio.NewScanner(gzIn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxArchiveLine)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		var record archivedUser
+		if err := json.Unmarshal(line, &record); err != nil {
+			return 0, fmt.Errorf("failed to decode archive %s: %v", filepath.Base(path), err)
+		}
+		if a.recordSubject(record) != subject || !inTenant(record.TenantID, tenant) {
+			buf.Write(line)
+			buf.WriteByte('\n')
+			continue
+		}
+
+		affected++
+		if mode == repositories.ErasureDelete {
+			continue
+		}
+		anonymized, err := json.Marshal(anonymize(record))
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode archive record: %v", err)
+		}
+		buf.Write(anonymized)
+		buf.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read archive %s: %v", filepath.Base(path), err)
+	}
+	if affected == 0 {
+		return 0, nil
+	}
+
+	if err := buf.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to rewrite archive %s: %v", filepath.Base(path), err)
+	}
+	if err := gzOut.Close(); err != nil {
+		return 0, fmt.Errorf("failed to rewrite archive %s: %v", filepath.Base(path), err)
+	}
+	if err := out.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to rewrite archive %s: %v", filepath.Base(path), err)
+	}
+	if err := out.Close(); err != nil {
+		return 0, fmt.Errorf("failed to rewrite archive %s: %v", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, fmt.Errorf("failed to replace archive %s: %v", filepath.Base(path), err)
+	}
+	return affected, nil
+}
+
+// recordSubject subject_hash do registro; arquivos gravados antes dele guardam o
+// número do documento em claro
+func (a *NDJSONArchiver) recordSubject(record archivedUser) string {
+	if record.SubjectHash == "" && record.Document.DocumentNumber != "" {
+		return entities.SubjectHash(a.subjectHashKey, record.Document.DocumentNumber)
+	}
+	return record.SubjectHash
+}
+
+// anonymize remove os dados pessoais de um registro arquivado, como os repositórios
+func anonymize(record archivedUser) archivedUser {
+	record.Name = entities.ErasedPlaceholder
+	record.Document.DocumentNumber = "erased:" + record.ID.Hex()
+	record.Address.Street = ""
+	record.Address.Zipcode = ""
+	record.Status = entities.ErasedStatus
+	record.Message = entities.ErasedMessage
+	record.History = nil
+	record.SubjectHash = ""
+	return record
+}
+
+// inTenant registros arquivados antes da multi-tenancy pertencem ao tenant padrão
+func inTenant(recordTenant, tenant string) bool {
+	if recordTenant == "" {
+		return tenant == entities.DefaultTenant
+	}
+	return recordTenant == tenant
+}
@@ -0,0 +1,129 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"api-rabbitmq/internal/application/usecases"
+	"api-rabbitmq/internal/domain/entities"
+)
+
+const archiveExt = ".ndjson.gz"
+
+// NDJSONArchiver grava usuários em arquivos NDJSON compactados com gzip. O número do
+// documento não é gravado: cada registro leva apenas o subject_hash do titular, que
+// permite eliminá-lo dos arquivos (ver EraseSubject). Os arquivos ainda contêm nome
+// e endereço, por isso são criados com permissão 0600.
+type NDJSONArchiver struct {
+	dir            string
+	subjectHashKey []byte
+	// mu é mantido por um arquivamento do Create ao Close, para que uma eliminação
+	// não deixe passar um arquivo ainda em gravação
+	mu sync.Mutex
+}
+
+func NewNDJSONArchiver(dir, subjectHashKey string) *NDJSONArchiver {
+	return &NDJSONArchiver{dir: dir, subjectHashKey: []byte(subjectHashKey)}
+}
+
+// archivedUser registro de um arquivo de retenção
+type archivedUser struct {
+	entities.ProcessedUser
+	SubjectHash string `json:"subject_hash,omitempty"`
+}
+
+func (a *NDJSONArchiver) Create(name string) (usecases.ArchiveWriter, error) {
+	a.mu.Lock()
+	release := sync.OnceFunc(a.mu.Unlock)
+
+	if err := os.MkdirAll(a.dir, 0o700); err != nil {
+		release()
+		return nil, fmt.Errorf("failed to create archive directory: %v", err)
+	}
+
+	path := filepath.Join(a.dir, fmt.Sprintf("%s-%s%s", name, time.Now().UTC().Format("20060102T150405Z"), archiveExt))
+	// Arquivo temporário renomeado apenas no Close: um arquivo .ndjson.gz está sempre completo
+	file, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("failed to create archive file: %v", err)
+	}
+
+	gz := gzip.NewWriter(file)
+	buf := bufio.NewWriter(gz)
+	return &ndjsonWriter{
+		archiver: a,
+		release:  release,
+		path:     path,
+		file:     file,
+		gzip:     gz,
+		buf:      buf,
+		encoder:  json.NewEncoder(buf),
+	}, nil
+}
+
+type ndjsonWriter struct {
+	archiver *NDJSONArchiver
+	release  func()
+	path     string
+	file     *os.File
+	gzip     *gzip.Writer
+	buf      *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(user *entities.ProcessedUser) error {
+	record := archivedUser{
+		ProcessedUser: *user,
+		SubjectHash:   entities.SubjectHash(w.archiver.subjectHashKey, user.Document.DocumentNumber),
+	}
+	record.Document.DocumentNumber = ""
+	record.Document.DocumentHash = ""
+
+	if err := w.encoder.Encode(record); err != nil {
+		return fmt.Errorf("failed to write archive record: %v", err)
+	}
+	return nil
+}
+
+func (w *ndjsonWriter) Close() (string, error) {
+	defer w.release()
+
+	if err := w.buf.Flush(); err != nil {
+		return "", w.abort(err)
+	}
+	if err := w.gzip.Close(); err != nil {
+		return "", w.abort(err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return "", w.abort(err)
+	}
+	if err := w.file.Close(); err != nil {
+		return "", w.abort(err)
+	}
+	if err := os.Rename(w.path+".tmp", w.path); err != nil {
+		return "", fmt.Errorf("failed to finalize archive: %v", err)
+	}
+	return w.path, nil
+}
+
+func (w *ndjsonWriter) Abort() {
+	w.abort(nil)
+}
+
+func (w *ndjsonWriter) abort(cause error) error {
+	defer w.release()
+
+	w.file.Close()
+	os.Remove(w.path + ".tmp")
+	if cause == nil {
+		return nil
+	}
+	return fmt.Errorf("failed to write archive: %v", cause)
+}
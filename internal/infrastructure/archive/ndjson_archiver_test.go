@@ -0,0 +1,118 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+func archivedUserFixture(name, document string) *entities.ProcessedUser {
+	return &entities.ProcessedUser{
+		ID:       primitive.NewObjectID(),
+		TenantID: entities.DefaultTenant,
+		Name:     name,
+		Document: entities.DocumentUserProcessed{DocumentNumber: document, IsValid: true},
+		Address:  entities.AddressResponse{Street: "Rua das Flores", City: "Campinas", State: "SP", Zipcode: "13086656"},
+		Status:   "processed",
+	}
+}
+
+func readArchive(t *testing.T, path string) string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestArchiveOmitsDocumentNumbersAndHonoursErasure(t *testing.T) {
+	dir := t.TempDir()
+	archiver := NewNDJSONArchiver(dir, "subject-key")
+	ctx := entities.WithTenant(context.Background(), entities.DefaultTenant)
+
+	writer, err := archiver.Create("processed_users-default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range []*entities.ProcessedUser{
+		archivedUserFixture("Maria da Silva", "52998224725"),
+		archivedUserFixture("João Souza", "11144477735"),
+	} {
+		if err := writer.Write(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path, err := writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content := readArchive(t, path); strings.Contains(content, "52998224725") || strings.Contains(content, "11144477735") {
+		t.Fatalf("archive contains a document number:\n%s", content)
+	}
+
+	// Arquivo gravado antes do subject_hash, com o documento em claro
+	var legacy bytes.Buffer
+	gz := gzip.NewWriter(&legacy)
+	json.NewEncoder(gz).Encode(archivedUserFixture("Maria da Silva", "52998224725"))
+	gz.Close()
+	legacyPath := filepath.Join(dir, "processed_users-legacy"+archiveExt)
+	if err := os.WriteFile(legacyPath, legacy.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	affected, err := archiver.EraseSubject(ctx, "529.982.247-25", repositories.ErasureAnonymize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected != 2 {
+		t.Fatalf("anonymized %d records, want 2", affected)
+	}
+	for _, p := range []string{path, legacyPath} {
+		content := readArchive(t, p)
+		if strings.Contains(content, "Maria") || strings.Contains(content, "52998224725") {
+			t.Fatalf("%s still holds the erased subject:\n%s", filepath.Base(p), content)
+		}
+		if !strings.Contains(content, entities.ErasedPlaceholder) {
+			t.Fatalf("%s has no anonymized record:\n%s", filepath.Base(p), content)
+		}
+	}
+
+	// Outro tenant não alcança os registros do tenant padrão
+	other := entities.WithTenant(context.Background(), "acme")
+	if affected, err := archiver.EraseSubject(other, "11144477735", repositories.ErasureDelete); err != nil || affected != 0 {
+		t.Fatalf("erasure from another tenant affected %d records (err %v), want 0", affected, err)
+	}
+
+	affected, err = archiver.EraseSubject(ctx, "11144477735", repositories.ErasureDelete)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected != 1 {
+		t.Fatalf("deleted %d records, want 1", affected)
+	}
+	if content := readArchive(t, path); strings.Contains(content, "João") || strings.Count(content, "\n") != 1 {
+		t.Fatalf("archive after delete:\n%s", content)
+	}
+}
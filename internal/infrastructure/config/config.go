@@ -6,6 +6,10 @@ type Config struct {
 	Database     DatabaseConfig
 	RabbitMQ     RabbitMQConfig
 	ExternalAPIs ExternalAPIsConfig
+	Privacy      PrivacyConfig
+	Logging      LoggingConfig
+	Retention    RetentionConfig
+	Tenancy      TenancyConfig
 	Environment  string
 }
 
@@ -16,6 +20,10 @@ func NewConfig() *Config {
 		Database:     LoadDatabaseConfig(),
 		RabbitMQ:     LoadRabbitMQConfig(),
 		ExternalAPIs: LoadExternalAPIsConfig(),
+		Privacy:      LoadPrivacyConfig(),
+		Logging:      LoadLoggingConfig(),
+		Retention:    LoadRetentionConfig(),
+		Tenancy:      LoadTenancyConfig(),
 		Environment:  GetEnv("APP_ENV", "development"),
 	}
 }
@@ -7,23 +7,44 @@ import (
 
 // DatabaseConfig configurações do banco de dados
 type DatabaseConfig struct {
+	// Driver implementação dos repositórios: mongodb ou inmemory (desenvolvimento e testes)
+	Driver            string
 	URI               string
 	DatabaseName      string
+	CollectionName    string
 	ConnectionTimeout time.Duration
 	MaxPoolSize       uint64
 	MinPoolSize       uint64
 	SSL               bool
+	TLSCAFile         string
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSInsecure       bool
+	DedupePolicy      string
 }
 
+// Drivers de repositório aceitos
+const (
+	DriverMongoDB  = "mongodb"
+	DriverInMemory = "inmemory"
+)
+
 // LoadDatabaseConfig carrega configurações do banco de dados
 func LoadDatabaseConfig() DatabaseConfig {
 	return DatabaseConfig{
+		Driver:            GetEnv("REPOSITORY_DRIVER", DriverMongoDB),
 		URI:               GetEnv("MONGODB_URI", "mongodb://localhost:27017/userdb"),
 		DatabaseName:      GetEnv("MONGODB_DATABASE", "userdb"),
+		CollectionName:    GetEnv("MONGODB_COLLECTION", "processed_users"),
 		ConnectionTimeout: GetEnvDuration("MONGODB_CONNECTION_TIMEOUT", 10*time.Second),
 		MaxPoolSize:       uint64(GetEnvInt("MONGODB_MAX_POOL_SIZE", 100)),
 		MinPoolSize:       uint64(GetEnvInt("MONGODB_MIN_POOL_SIZE", 1)),
 		SSL:               GetEnvBool("MONGODB_SSL", false),
+		TLSCAFile:         GetEnv("MONGODB_TLS_CA_FILE", ""),
+		TLSCertFile:       GetEnv("MONGODB_TLS_CERT_FILE", ""),
+		TLSKeyFile:        GetEnv("MONGODB_TLS_KEY_FILE", ""),
+		TLSInsecure:       GetEnvBool("MONGODB_TLS_INSECURE", false),
+		DedupePolicy:      GetEnv("MONGODB_DEDUPE_POLICY", "upsert"),
 	}
 }
 
@@ -34,11 +55,38 @@ func (d *DatabaseConfig) GetConnectionString() string {
 
 // Validate valida as configurações do banco de dados
 func (d *DatabaseConfig) Validate() error {
+	switch d.Driver {
+	case DriverMongoDB:
+	case DriverInMemory:
+		// Apenas a política de deduplicação e o nome da collection se aplicam
+		return d.validateDedupePolicy()
+	default:
+		return fmt.Errorf("invalid repository driver %q (expected mongodb or inmemory)", d.Driver)
+	}
+
 	if d.URI == "" {
 		return fmt.Errorf("database URI is required")
 	}
 	if d.DatabaseName == "" {
 		return fmt.Errorf("database name is required")
 	}
+	if d.CollectionName == "" {
+		return fmt.Errorf("collection name is required")
+	}
+	if d.MinPoolSize > d.MaxPoolSize && d.MaxPoolSize != 0 {
+		return fmt.Errorf("min pool size (%d) must not exceed max pool size (%d)", d.MinPoolSize, d.MaxPoolSize)
+	}
+	if (d.TLSCertFile == "") != (d.TLSKeyFile == "") {
+		return fmt.Errorf("TLS cert and key files must be set together")
+	}
+	return d.validateDedupePolicy()
+}
+
+func (d *DatabaseConfig) validateDedupePolicy() error {
+	switch d.DedupePolicy {
+	case "upsert", "reject", "insert":
+	default:
+		return fmt.Errorf("invalid dedupe policy %q (expected upsert, reject or insert)", d.DedupePolicy)
+	}
 	return nil
 }
@@ -12,6 +12,13 @@ type ExternalAPIsConfig struct {
 	Timeout               time.Duration
 	RetryAttempts         int
 	RetryDelay            time.Duration
+	MaxResponseBytes      int64
+
+	// Limites de requisições por segundo (0 desativa) e rajada por endpoint
+	DocumentValidationRateLimit float64
+	DocumentValidationBurst     int
+	AddressServiceRateLimit     float64
+	AddressServiceBurst         int
 }
 
 // LoadExternalAPIsConfig carrega configurações das APIs externas
@@ -22,6 +29,12 @@ func LoadExternalAPIsConfig() ExternalAPIsConfig {
 		Timeout:               GetEnvDuration("EXTERNAL_API_TIMEOUT", 30*time.Second),
 		RetryAttempts:         GetEnvInt("EXTERNAL_API_RETRY_ATTEMPTS", 3),
 		RetryDelay:            GetEnvDuration("EXTERNAL_API_RETRY_DELAY", 1*time.Second),
+		MaxResponseBytes:      int64(GetEnvInt("EXTERNAL_API_MAX_RESPONSE_BYTES", 1<<20)),
+
+		DocumentValidationRateLimit: GetEnvFloat("DOCUMENT_VALIDATION_RATE_LIMIT", 0),
+		DocumentValidationBurst:     GetEnvInt("DOCUMENT_VALIDATION_BURST", 1),
+		AddressServiceRateLimit:     GetEnvFloat("ADDRESS_SERVICE_RATE_LIMIT", 0),
+		AddressServiceBurst:         GetEnvInt("ADDRESS_SERVICE_BURST", 1),
 	}
 }
 
@@ -33,5 +46,8 @@ func (e *ExternalAPIsConfig) Validate() error {
 	if e.AddressServiceURL == "" {
 		return fmt.Errorf("address service URL is required")
 	}
+	if e.DocumentValidationRateLimit < 0 || e.AddressServiceRateLimit < 0 {
+		return fmt.Errorf("external API rate limits must not be negative")
+	}
 	return nil
 }
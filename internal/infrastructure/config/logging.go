@@ -0,0 +1,20 @@
+package config
+
+// LoggingConfig configurações de log
+type LoggingConfig struct {
+	// MaskPII mascara nomes, documentos e endereços nos logs
+	MaskPII bool
+}
+
+// LoadLoggingConfig carrega configurações de log
+func LoadLoggingConfig() LoggingConfig {
+	return LoggingConfig{
+		MaskPII: GetEnvBool("LOG_MASK_PII", true),
+	}
+}
+
+// PIIMaskingEnabled indica se os dados pessoais devem ser mascarados. Em produção o
+// mascaramento é sempre aplicado, independente de LOG_MASK_PII.
+func (c *Config) PIIMaskingEnabled() bool {
+	return c.Logging.MaskPII || c.IsProduction()
+}
@@ -0,0 +1,54 @@
+package config
+
+import "fmt"
+
+// PrivacyConfig configurações de proteção de dados pessoais (LGPD)
+type PrivacyConfig struct {
+	ErasureMode    string
+	SubjectHashKey string
+
+	// Criptografia de campos: chaves "<id>:<base64>" inline ou em arquivo
+	EncryptionKeys        string
+	EncryptionKeysFile    string
+	EncryptionActiveKeyID string
+	BlindIndexKey         string
+}
+
+// LoadPrivacyConfig carrega configurações de privacidade
+func LoadPrivacyConfig() PrivacyConfig {
+	return PrivacyConfig{
+		ErasureMode:    GetEnv("LGPD_ERASURE_MODE", "delete"),
+		SubjectHashKey: GetEnv("LGPD_SUBJECT_HASH_KEY", ""),
+
+		EncryptionKeys:        GetEnv("ENCRYPTION_KEYS", ""),
+		EncryptionKeysFile:    GetEnv("ENCRYPTION_KEYS_FILE", ""),
+		EncryptionActiveKeyID: GetEnv("ENCRYPTION_ACTIVE_KEY_ID", ""),
+		BlindIndexKey:         GetEnv("BLIND_INDEX_KEY", ""),
+	}
+}
+
+// Validate valida as configurações de privacidade
+func (p *PrivacyConfig) Validate() error {
+	switch p.ErasureMode {
+	case "delete", "anonymize":
+	default:
+		return fmt.Errorf("invalid erasure mode %q (expected delete or anonymize)", p.ErasureMode)
+	}
+	if p.SubjectHashKey == "" {
+		return fmt.Errorf("LGPD subject hash key is required")
+	}
+	if p.EncryptionEnabled() {
+		if p.EncryptionActiveKeyID == "" {
+			return fmt.Errorf("encryption active key ID is required when encryption keys are set")
+		}
+		if p.BlindIndexKey == "" {
+			return fmt.Errorf("blind index key is required when encryption keys are set")
+		}
+	}
+	return nil
+}
+
+// EncryptionEnabled indica se há chaves de criptografia configuradas
+func (p *PrivacyConfig) EncryptionEnabled() bool {
+	return p.EncryptionKeys != "" || p.EncryptionKeysFile != ""
+}
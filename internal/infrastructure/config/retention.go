@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// RetentionConfig política de retenção dos usuários processados
+type RetentionConfig struct {
+	// Days dias de retenção; 0 desativa a política
+	Days int
+	// Mode "ttl" (índice TTL do MongoDB) ou "archive" (job que arquiva e remove)
+	Mode       string
+	Interval   time.Duration
+	ArchiveDir string
+}
+
+// LoadRetentionConfig carrega a política de retenção
+func LoadRetentionConfig() RetentionConfig {
+	return RetentionConfig{
+		Days:       GetEnvInt("RETENTION_DAYS", 0),
+		Mode:       GetEnv("RETENTION_MODE", "archive"),
+		Interval:   GetEnvDuration("RETENTION_INTERVAL", 24*time.Hour),
+		ArchiveDir: GetEnv("RETENTION_ARCHIVE_DIR", "./archive"),
+	}
+}
+
+// Enabled indica se a política de retenção está ativa
+func (r *RetentionConfig) Enabled() bool {
+	return r.Days > 0
+}
+
+// Period retorna o período de retenção
+func (r *RetentionConfig) Period() time.Duration {
+	return time.Duration(r.Days) * 24 * time.Hour
+}
+
+// Validate valida a política de retenção
+func (r *RetentionConfig) Validate() error {
+	if r.Days < 0 {
+		return fmt.Errorf("retention days must not be negative")
+	}
+	switch r.Mode {
+	case "ttl", "archive":
+	default:
+		return fmt.Errorf("invalid retention mode %q (expected ttl or archive)", r.Mode)
+	}
+	if r.Mode == "archive" && r.Enabled() {
+		if r.Interval <= 0 {
+			return fmt.Errorf("retention interval must be positive")
+		}
+		if r.ArchiveDir == "" {
+			return fmt.Errorf("retention archive directory is required")
+		}
+	}
+	return nil
+}
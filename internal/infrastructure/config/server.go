@@ -7,21 +7,32 @@ import (
 
 // ServerConfig configurações do servidor HTTP
 type ServerConfig struct {
-	Port         string
+	Port string
+	// Host interface de escuta; vazio escuta em todas
 	Host         string
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+	// ShutdownTimeout prazo total do encerramento: concluir o trabalho em andamento e fechar as conexões
+	ShutdownTimeout time.Duration
+	// HealthCheckTimeout prazo de cada verificação de dependência do /readyz
+	HealthCheckTimeout time.Duration
+	// HealthCheckCacheTTL por quanto tempo o /readyz reaproveita a verificação das APIs
+	// externas, em vez de consultá-las a cada probe; 0 desativa o cache
+	HealthCheckCacheTTL time.Duration
 }
 
 // LoadServerConfig carrega configurações do servidor
 func LoadServerConfig() ServerConfig {
 	return ServerConfig{
-		Port:         GetEnv("SERVER_PORT", "8080"),
-		Host:         GetEnv("SERVER_HOST", "localhost"),
-		ReadTimeout:  GetEnvDuration("SERVER_READ_TIMEOUT", 30*time.Second),
-		WriteTimeout: GetEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
-		IdleTimeout:  GetEnvDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+		Port:                GetEnv("SERVER_PORT", "8080"),
+		Host:                GetEnv("SERVER_HOST", ""),
+		ReadTimeout:         GetEnvDuration("SERVER_READ_TIMEOUT", 30*time.Second),
+		WriteTimeout:        GetEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:         GetEnvDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+		ShutdownTimeout:     GetEnvDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+		HealthCheckTimeout:  GetEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		HealthCheckCacheTTL: GetEnvDuration("HEALTH_CHECK_CACHE_TTL", 30*time.Second),
 	}
 }
 
@@ -35,5 +46,17 @@ func (s *ServerConfig) Validate() error {
 	if s.Port == "" {
 		return fmt.Errorf("server port is required")
 	}
+	if s.ReadTimeout < 0 || s.WriteTimeout < 0 || s.IdleTimeout < 0 {
+		return fmt.Errorf("server timeouts must not be negative")
+	}
+	if s.ShutdownTimeout <= 0 {
+		return fmt.Errorf("server shutdown timeout must be positive")
+	}
+	if s.HealthCheckTimeout <= 0 {
+		return fmt.Errorf("health check timeout must be positive")
+	}
+	if s.HealthCheckCacheTTL < 0 {
+		return fmt.Errorf("health check cache TTL must not be negative")
+	}
 	return nil
 }
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"api-rabbitmq/internal/domain/entities"
+)
+
+// TenancyConfig isolamento entre unidades de negócio que compartilham a implantação
+type TenancyConfig struct {
+	// Enabled exige um tenant em cada requisição e mensagem; desligado, tudo
+	// pertence a entities.DefaultTenant
+	Enabled bool
+	// Tenants IDs aceitos
+	Tenants []string
+	// APIKeys vincula chaves de API a tenants (chave -> tenant). Uma requisição com
+	// chave vinculada pertence ao tenant da chave; com vínculos configurados, toda
+	// requisição precisa de uma chave vinculada.
+	APIKeys map[string]string
+	// DedicatedDatabases tenants com banco próprio, "<MONGODB_DATABASE>_<tenant>"
+	DedicatedDatabases []string
+	// DedicatedQueues tenants com fila própria, "<fila>.<tenant>"
+	DedicatedQueues []string
+
+	// malformedAPIKeys entradas de TENANT_API_KEYS sem tenant, rejeitadas em Validate
+	malformedAPIKeys int
+}
+
+// LoadTenancyConfig carrega as configurações de multi-tenancy. TENANT_API_KEYS usa o
+// formato "tenant:chave,tenant:chave".
+func LoadTenancyConfig() TenancyConfig {
+	t := TenancyConfig{
+		Enabled:            GetEnvBool("TENANCY_ENABLED", false),
+		Tenants:            GetEnvList("TENANTS"),
+		APIKeys:            make(map[string]string),
+		DedicatedDatabases: GetEnvList("TENANT_DEDICATED_DATABASES"),
+		DedicatedQueues:    GetEnvList("TENANT_DEDICATED_QUEUES"),
+	}
+	for _, binding := range GetEnvList("TENANT_API_KEYS") {
+		tenant, key, ok := strings.Cut(binding, ":")
+		if !ok || tenant == "" || key == "" {
+			t.malformedAPIKeys++
+			continue
+		}
+		t.APIKeys[key] = tenant
+	}
+	return t
+}
+
+// Validate valida as configurações de multi-tenancy
+func (t *TenancyConfig) Validate() error {
+	if !t.Enabled {
+		if len(t.Tenants) > 0 || len(t.APIKeys) > 0 || t.malformedAPIKeys > 0 || len(t.DedicatedDatabases) > 0 || len(t.DedicatedQueues) > 0 {
+			return fmt.Errorf("tenant settings require TENANCY_ENABLED=true")
+		}
+		return nil
+	}
+
+	if len(t.Tenants) == 0 {
+		return fmt.Errorf("at least one tenant is required when tenancy is enabled")
+	}
+	for _, tenant := range t.Tenants {
+		if !entities.ValidTenantID(tenant) {
+			return fmt.Errorf("invalid tenant ID %q (expected lowercase letters, digits, '-' or '_', up to 32 characters)", tenant)
+		}
+	}
+	// Registros gravados antes da multi-tenancy são migrados para o tenant padrão
+	if !contains(t.Tenants, entities.DefaultTenant) {
+		return fmt.Errorf("TENANTS must include %q, which owns the records written before tenancy was enabled", entities.DefaultTenant)
+	}
+	if t.malformedAPIKeys > 0 {
+		// A mensagem não repete as entradas: contêm chaves
+		return fmt.Errorf("%d tenant API key bindings are malformed (expected tenant:key)", t.malformedAPIKeys)
+	}
+	for _, tenant := range t.APIKeys {
+		if !t.Known(tenant) {
+			return fmt.Errorf("API key bound to unknown tenant %q", tenant)
+		}
+	}
+	for _, tenant := range append(append([]string(nil), t.DedicatedDatabases...), t.DedicatedQueues...) {
+		if !t.Known(tenant) {
+			return fmt.Errorf("dedicated resource configured for unknown tenant %q", tenant)
+		}
+	}
+	return nil
+}
+
+// Known indica se tenant é aceito
+func (t *TenancyConfig) Known(tenant string) bool {
+	if !t.Enabled {
+		return tenant == entities.DefaultTenant
+	}
+	return contains(t.Tenants, tenant)
+}
+
+// All lista os tenants da implantação (apenas o padrão com a multi-tenancy desligada)
+func (t *TenancyConfig) All() []string {
+	if !t.Enabled {
+		return []string{entities.DefaultTenant}
+	}
+	return t.Tenants
+}
+
+// TenantForAPIKey retorna o tenant vinculado à chave, se houver
+func (t *TenancyConfig) TenantForAPIKey(key string) (string, bool) {
+	if !t.Enabled || key == "" {
+		return "", false
+	}
+	tenant, ok := t.APIKeys[key]
+	return tenant, ok
+}
+
+// DatabaseName banco do tenant: o dedicado, se configurado, ou base
+func (t *TenancyConfig) DatabaseName(base, tenant string) string {
+	if t.HasDedicatedDatabase(tenant) {
+		return base + "_" + tenant
+	}
+	return base
+}
+
+// HasDedicatedDatabase indica se tenant tem banco próprio
+func (t *TenancyConfig) HasDedicatedDatabase(tenant string) bool {
+	return t.Enabled && contains(t.DedicatedDatabases, tenant)
+}
+
+// QueueName fila do tenant: a dedicada, se configurada, ou base
+func (t *TenancyConfig) QueueName(base, tenant string) string {
+	if t.Enabled && contains(t.DedicatedQueues, tenant) {
+		return base + "." + tenant
+	}
+	return base
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
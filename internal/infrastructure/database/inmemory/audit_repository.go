@@ -0,0 +1,138 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const auditName = "user_audit"
+
+// AuditRepository trilha de auditoria em memória
+type AuditRepository struct {
+	mu     sync.RWMutex
+	events []entities.AuditEvent
+}
+
+func NewAuditRepository() repositories.AuditRepository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) Append(ctx context.Context, event *entities.AuditEvent) error {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.ID = primitive.NewObjectID()
+	event.TenantID = tenant
+	stored := *event
+	stored.Changes = append([]entities.FieldChange(nil), event.Changes...)
+	r.events = append(r.events, stored)
+	return nil
+}
+
+func (r *AuditRepository) FindByUser(ctx context.Context, userID string, limit int) ([]entities.AuditEvent, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, fmt.Errorf("%w: %v", repositories.ErrInvalidID, err)
+	}
+	if limit <= 0 {
+		limit = repositories.DefaultHistoryLimit
+	}
+
+	r.mu.RLock()
+	events := []entities.AuditEvent{}
+	for _, e := range r.events {
+		if e.UserID == userID && inTenant(e.TenantID, tenant) {
+			e.Changes = append([]entities.FieldChange(nil), e.Changes...)
+			events = append(events, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].OccurredAt.Equal(events[j].OccurredAt) {
+			return events[i].OccurredAt.After(events[j].OccurredAt)
+		}
+		return compareIDs(events[i].ID, events[j].ID) > 0
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *AuditRepository) Name() string {
+	return auditName
+}
+
+func (r *AuditRepository) EraseSubject(ctx context.Context, documentNumber string, mode repositories.ErasureMode) (int64, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return 0, err
+	}
+	key := entities.NormalizeDocumentNumber(documentNumber)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var affected int64
+	kept := r.events[:0]
+	for _, e := range r.events {
+		if !slices.Contains(e.SubjectKeys, key) || !inTenant(e.TenantID, tenant) {
+			kept = append(kept, e)
+			continue
+		}
+		affected++
+		if mode == repositories.ErasureDelete {
+			continue
+		}
+		e.Changes = nil
+		e.SubjectKeys = nil
+		e.Redacted = true
+		kept = append(kept, e)
+	}
+	r.events = kept
+	return affected, nil
+}
+
+// recordAudit grava a alteração na trilha de auditoria, como no repositório MongoDB
+func (r *UserRepository) recordAudit(ctx context.Context, action entities.AuditAction, id primitive.ObjectID, before, after *entities.ProcessedUser) {
+	if r.audit == nil {
+		return
+	}
+
+	origin := entities.AuditFromContext(ctx)
+	event := &entities.AuditEvent{
+		UserID:        id.Hex(),
+		Action:        action,
+		Actor:         origin.Actor,
+		CorrelationID: origin.CorrelationID,
+		Changes:       entities.DiffProcessedUser(before, after),
+		OccurredAt:    time.Now(),
+	}
+	if after != nil {
+		event.Version = after.Version
+	}
+	event.SubjectKeys = entities.SubjectDocuments(before, after)
+
+	if err := r.audit.Append(ctx, event); err != nil {
+		log.Printf("Warning: failed to write audit event for user %s: %v", id.Hex(), err)
+	}
+}
@@ -0,0 +1,40 @@
+package inmemory
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/infrastructure/config"
+	"api-rabbitmq/internal/infrastructure/database/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, opts repotest.Options) repotest.Repositories {
+		audit := NewAuditRepository()
+		users, err := NewUserRepository(
+			&config.DatabaseConfig{CollectionName: "processed_users", DedupePolicy: string(opts.DedupePolicy)},
+			UserRepositoryOptions{Audit: audit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { users.Close() })
+
+		repo := users.(*UserRepository)
+		return repotest.Repositories{
+			Users: users,
+			Audit: audit,
+			InsertLegacy: func(t *testing.T, user *entities.ProcessedUser) {
+				at := now()
+				user.ID = primitive.NewObjectID()
+				user.TenantID = ""
+				user.CreatedAt, user.UpdatedAt, user.Version = at, at, 1
+
+				repo.mu.Lock()
+				defer repo.mu.Unlock()
+				repo.users[user.ID] = cloneUser(user)
+			},
+		}
+	})
+}
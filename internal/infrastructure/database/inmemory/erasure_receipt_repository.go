@@ -0,0 +1,54 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErasureReceiptRepository cadeia de recibos de eliminação em memória
+type ErasureReceiptRepository struct {
+	mu       sync.Mutex
+	receipts []entities.ErasureReceipt
+}
+
+func NewErasureReceiptRepository() repositories.ErasureReceiptRepository {
+	return &ErasureReceiptRepository{}
+}
+
+func (r *ErasureReceiptRepository) Append(ctx context.Context, receipt *entities.ErasureReceipt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var last entities.ErasureReceipt
+	if n := len(r.receipts); n > 0 {
+		last = r.receipts[n-1]
+	}
+
+	receipt.ID = primitive.NewObjectID()
+	receipt.Sequence = last.Sequence + 1
+	receipt.PreviousHash = last.Hash
+	receipt.Hash = receipt.ComputeHash()
+	r.receipts = append(r.receipts, *receipt)
+	return nil
+}
+
+func (r *ErasureReceiptRepository) ForEach(ctx context.Context, fn func(receipt *entities.ErasureReceipt) error) error {
+	r.mu.Lock()
+	receipts := append([]entities.ErasureReceipt(nil), r.receipts...)
+	r.mu.Unlock()
+
+	for i := range receipts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(&receipts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
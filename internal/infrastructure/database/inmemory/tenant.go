@@ -0,0 +1,23 @@
+package inmemory
+
+import (
+	"context"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+// tenantOf retorna o tenant de ctx; ErrTenantRequired quando não há um
+func tenantOf(ctx context.Context) (string, error) {
+	tenant, ok := entities.TenantFromContext(ctx)
+	if !ok {
+		return "", repositories.ErrTenantRequired
+	}
+	return tenant, nil
+}
+
+// inTenant indica se tenantID pertence a tenant; como no MongoDB, registros sem
+// tenant são do tenant padrão
+func inTenant(tenantID, tenant string) bool {
+	return tenantID == tenant || (tenantID == "" && tenant == entities.DefaultTenant)
+}
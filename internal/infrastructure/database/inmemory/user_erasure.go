@@ -0,0 +1,51 @@
+package inmemory
+
+import (
+	"context"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+func (r *UserRepository) Name() string {
+	return r.name
+}
+
+// EraseSubject remove ou anonimiza os registros do documento com os mesmos valores
+// da implementação MongoDB
+func (r *UserRepository) EraseSubject(ctx context.Context, documentNumber string, mode repositories.ErasureMode) (int64, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return 0, err
+	}
+	normalized := entities.NormalizeDocumentNumber(documentNumber)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var affected int64
+	for id, u := range r.users {
+		if u.Document.DocumentNumber != normalized || !inTenant(u.TenantID, tenant) {
+			continue
+		}
+		affected++
+
+		if mode == repositories.ErasureDelete {
+			delete(r.users, id)
+			r.feed.publish(tenant, repositories.UserChange{Type: repositories.ChangeDelete, UserID: id.Hex(), At: now()})
+			continue
+		}
+
+		u.Name = entities.ErasedPlaceholder
+		u.Document.DocumentNumber = "erased:" + id.Hex()
+		u.Address.Street = ""
+		u.Address.Zipcode = ""
+		u.Status = entities.ErasedStatus
+		u.Message = entities.ErasedMessage
+		u.UpdatedAt = now()
+		u.Version++
+		u.History = nil
+		r.feed.publish(tenant, repositories.UserChange{Type: repositories.ChangeUpdate, UserID: id.Hex(), User: cloneUser(u), At: now()})
+	}
+	return affected, nil
+}
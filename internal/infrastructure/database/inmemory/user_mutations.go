@@ -0,0 +1,151 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (r *UserRepository) Update(ctx context.Context, id string, update entities.ProcessedUserUpdate, expectedVersion int64) (*entities.ProcessedUser, error) {
+	return r.applyUpdate(ctx, id, expectedVersion, func(u *entities.ProcessedUser) {
+		u.Name = update.Name
+		u.Document = update.Document
+		u.Address = update.Address
+		u.Status = update.Status
+		u.Message = update.Message
+	})
+}
+
+func (r *UserRepository) Patch(ctx context.Context, id string, patch entities.ProcessedUserPatch, expectedVersion int64) (*entities.ProcessedUser, error) {
+	return r.applyUpdate(ctx, id, expectedVersion, func(u *entities.ProcessedUser) {
+		if patch.Name != nil {
+			u.Name = *patch.Name
+		}
+		if patch.Document != nil && patch.Document.DocumentNumber != nil {
+			u.Document.DocumentNumber = *patch.Document.DocumentNumber
+		}
+		if patch.Document != nil && patch.Document.IsValid != nil {
+			u.Document.IsValid = *patch.Document.IsValid
+		}
+		if patch.Address != nil {
+			u.Address = *patch.Address
+		}
+		if patch.Status != nil {
+			u.Status = *patch.Status
+		}
+		if patch.Message != nil {
+			u.Message = *patch.Message
+		}
+	})
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id string, expectedVersion int64) error {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", repositories.ErrInvalidID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, err := r.lockedForWrite(tenant, objectID, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	delete(r.users, objectID)
+	r.changed(ctx, entities.AuditDelete, repositories.ChangeDelete, user, nil)
+	return nil
+}
+
+func (r *UserRepository) DeleteVersions(ctx context.Context, refs []repositories.UserVersion) (int64, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	objectIDs := make([]primitive.ObjectID, 0, len(refs))
+	for _, ref := range refs {
+		objectID, err := primitive.ObjectIDFromHex(ref.ID)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", repositories.ErrInvalidID, err)
+		}
+		objectIDs = append(objectIDs, objectID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for i, objectID := range objectIDs {
+		u, ok := r.users[objectID]
+		if !ok || !inTenant(u.TenantID, tenant) || u.Version != refs[i].Version {
+			continue
+		}
+		delete(r.users, objectID)
+		deleted++
+
+		// Como no MongoDB, a remoção em lote registra apenas o fato, sem os valores
+		r.recordAudit(ctx, entities.AuditDelete, objectID, nil, nil)
+		r.feed.publish(tenant, repositories.UserChange{Type: repositories.ChangeDelete, UserID: objectID.Hex(), At: now()})
+	}
+	return deleted, nil
+}
+
+// applyUpdate aplica mutate a uma cópia do registro e a grava se a versão coincidir
+func (r *UserRepository) applyUpdate(ctx context.Context, id string, expectedVersion int64, mutate func(u *entities.ProcessedUser)) (*entities.ProcessedUser, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repositories.ErrInvalidID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before, err := r.lockedForWrite(tenant, objectID, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := cloneUser(before)
+	mutate(updated)
+	updated.Document.DocumentNumber = entities.NormalizeDocumentNumber(updated.Document.DocumentNumber)
+
+	// Equivalente ao índice único de documento nas políticas upsert e reject
+	if r.dedupePolicy != repositories.DedupeInsert && updated.Document.DocumentNumber != before.Document.DocumentNumber {
+		if other := r.findByDocument(tenant, updated.Document.DocumentNumber); other != nil {
+			return nil, repositories.ErrDuplicate
+		}
+	}
+
+	updated.UpdatedAt = now()
+	updated.Version++
+	r.users[objectID] = updated
+	r.changed(ctx, entities.AuditUpdate, repositories.ChangeUpdate, before, updated)
+
+	return cloneUser(updated), nil
+}
+
+// lockedForWrite retorna o registro se existir no tenant e estiver na versão esperada; exige r.mu
+func (r *UserRepository) lockedForWrite(tenant string, id primitive.ObjectID, expectedVersion int64) (*entities.ProcessedUser, error) {
+	user, ok := r.users[id]
+	if !ok || !inTenant(user.TenantID, tenant) {
+		return nil, fmt.Errorf("%w: %s", repositories.ErrNotFound, id.Hex())
+	}
+	if expectedVersion != repositories.AnyVersion && user.Version != expectedVersion {
+		return nil, repositories.ErrVersionConflict
+	}
+	return user, nil
+}
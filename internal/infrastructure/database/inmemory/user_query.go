@@ -0,0 +1,168 @@
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (r *UserRepository) Find(ctx context.Context, query repositories.UserQuery) (*repositories.UserPage, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+
+	users, err := r.selectUsers(tenant, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &repositories.UserPage{Items: users}
+	if len(users) > query.Limit {
+		page.Items = users[:query.Limit]
+		page.NextCursor = repositories.NewPageCursor(query, page.Items[query.Limit-1]).Encode()
+	}
+	return page, nil
+}
+
+func (r *UserRepository) ForEach(ctx context.Context, query repositories.UserQuery, fn func(user *entities.ProcessedUser) error) error {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	query.Cursor = ""
+	if err := query.Normalize(); err != nil {
+		return err
+	}
+	query.Limit = 0
+
+	// fn roda fora do lock: pode ser lento (exportação) ou alterar o repositório (retenção)
+	users, err := r.selectUsers(tenant, query)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(&users[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectUsers filtra pelo tenant e pela consulta, ordena e aplica o cursor; com
+// Limit > 0 retorna até Limit+1 registros para que Find saiba se há próxima página
+func (r *UserRepository) selectUsers(tenant string, query repositories.UserQuery) ([]entities.ProcessedUser, error) {
+	var after func(u *entities.ProcessedUser) bool
+	if query.Cursor != "" {
+		var err error
+		if after, err = keysetFilter(query); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.RLock()
+	users := make([]entities.ProcessedUser, 0)
+	for _, u := range r.users {
+		if inTenant(u.TenantID, tenant) && matches(u, query) && (after == nil || after(u)) {
+			users = append(users, *cloneUser(u))
+		}
+	}
+	r.mu.RUnlock()
+
+	sortUsers(users, query.SortBy, query.SortOrder)
+	if query.Limit > 0 && len(users) > query.Limit+1 {
+		users = users[:query.Limit+1]
+	}
+	return users, nil
+}
+
+func matches(u *entities.ProcessedUser, query repositories.UserQuery) bool {
+	switch {
+	case query.Status != "" && u.Status != query.Status:
+		return false
+	case query.DocumentValid != nil && u.Document.IsValid != *query.DocumentValid:
+		return false
+	case query.State != "" && u.Address.State != query.State:
+		return false
+	case query.City != "" && u.Address.City != query.City:
+		return false
+	case !query.CreatedFrom.IsZero() && u.CreatedAt.Before(query.CreatedFrom):
+		return false
+	case !query.CreatedTo.IsZero() && !u.CreatedAt.Before(query.CreatedTo):
+		return false
+	}
+	return true
+}
+
+// keysetFilter retorna a condição "depois do cursor", como na implementação MongoDB
+func keysetFilter(query repositories.UserQuery) (func(u *entities.ProcessedUser) bool, error) {
+	c, err := repositories.DecodeCursor(query.Cursor, query)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repositories.ErrInvalidCursor, err)
+	}
+
+	var createdAt time.Time
+	if c.SortBy == repositories.SortByCreatedAt {
+		if createdAt, err = c.CreatedAt(); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(u *entities.ProcessedUser) bool {
+		var cmp int
+		if c.SortBy == repositories.SortByName {
+			cmp = strings.Compare(u.Name, c.Value)
+		} else {
+			cmp = u.CreatedAt.Compare(createdAt)
+		}
+		if cmp == 0 {
+			cmp = compareIDs(u.ID, id)
+		}
+		if c.SortOrder == repositories.SortDescending {
+			return cmp < 0
+		}
+		return cmp > 0
+	}, nil
+}
+
+// sortUsers ordena pelo campo e desempata pelo ID, como sortSpec no MongoDB
+func sortUsers(users []entities.ProcessedUser, field repositories.SortField, order repositories.SortOrder) {
+	sort.Slice(users, func(i, j int) bool {
+		var cmp int
+		if field == repositories.SortByName {
+			cmp = strings.Compare(users[i].Name, users[j].Name)
+		} else {
+			cmp = users[i].CreatedAt.Compare(users[j].CreatedAt)
+		}
+		if cmp == 0 {
+			cmp = compareIDs(users[i].ID, users[j].ID)
+		}
+		if order == repositories.SortDescending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
+
+func compareIDs(a, b primitive.ObjectID) int {
+	return bytes.Compare(a[:], b[:])
+}
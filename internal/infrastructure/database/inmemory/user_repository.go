@@ -0,0 +1,194 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+	"api-rabbitmq/internal/infrastructure/config"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserRepository implementa repositories.UserRepository em memória, com a mesma
+// semântica da implementação MongoDB (IDs, ordenação, filtros, versões e erros).
+// Destinado a desenvolvimento local e testes: os dados se perdem ao encerrar o processo.
+type UserRepository struct {
+	mu           sync.RWMutex
+	users        map[primitive.ObjectID]*entities.ProcessedUser
+	name         string
+	dedupePolicy repositories.DedupePolicy
+	audit        repositories.AuditRepository
+	feed         *changeFeed
+}
+
+// UserRepositoryOptions recursos opcionais do repositório
+type UserRepositoryOptions struct {
+	// Audit, quando não nulo, recebe um evento para cada alteração de registro
+	Audit repositories.AuditRepository
+}
+
+func NewUserRepository(cfg *config.DatabaseConfig, opts UserRepositoryOptions) (repositories.UserRepository, error) {
+	dedupePolicy, err := repositories.ParseDedupePolicy(cfg.DedupePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserRepository{
+		users:        make(map[primitive.ObjectID]*entities.ProcessedUser),
+		name:         cfg.CollectionName,
+		dedupePolicy: dedupePolicy,
+		audit:        opts.Audit,
+		feed:         newChangeFeed(),
+	}, nil
+}
+
+// now segue a precisão de milissegundos do MongoDB, para que cursores e
+// comparações de data se comportem igual nas duas implementações
+func now() time.Time {
+	return time.Now().Truncate(time.Millisecond)
+}
+
+func (r *UserRepository) Save(ctx context.Context, user *entities.ProcessedUser) (*repositories.SaveResult, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.Document.DocumentNumber = entities.NormalizeDocumentNumber(user.Document.DocumentNumber)
+	existing := r.findByDocument(tenant, user.Document.DocumentNumber)
+
+	switch {
+	case existing != nil && r.dedupePolicy == repositories.DedupeReject:
+		return nil, repositories.ErrDuplicate
+	case existing != nil && r.dedupePolicy == repositories.DedupeUpsert:
+		before := cloneUser(existing)
+		at := now()
+		existing.TenantID = tenant
+		existing.Name = user.Name
+		existing.Document = user.Document
+		existing.Address = user.Address
+		existing.Status = user.Status
+		existing.Message = user.Message
+		existing.UpdatedAt = at
+		existing.Version++
+		existing.History = append(existing.History, entities.NewProcessingRecord(user, at))
+		if n := len(existing.History); n > repositories.MaxHistoryRecords {
+			existing.History = existing.History[n-repositories.MaxHistoryRecords:]
+		}
+
+		*user = *cloneUser(existing)
+		r.changed(ctx, entities.AuditReprocess, repositories.ChangeUpdate, before, existing)
+		return &repositories.SaveResult{ID: user.ID.Hex(), Created: false}, nil
+	}
+
+	at := now()
+	user.ID = primitive.NewObjectID()
+	user.TenantID = tenant
+	user.CreatedAt = at
+	user.UpdatedAt = at
+	user.Version = 1
+	user.History = []entities.ProcessingRecord{entities.NewProcessingRecord(user, at)}
+
+	stored := cloneUser(user)
+	r.users[stored.ID] = stored
+	r.changed(ctx, entities.AuditCreate, repositories.ChangeInsert, nil, stored)
+
+	return &repositories.SaveResult{ID: user.ID.Hex(), Created: true}, nil
+}
+
+func (r *UserRepository) FindAll(ctx context.Context) ([]entities.ProcessedUser, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]entities.ProcessedUser, 0, len(r.users))
+	for _, u := range r.users {
+		if inTenant(u.TenantID, tenant) {
+			users = append(users, *cloneUser(u))
+		}
+	}
+	// Sem ordenação definida no MongoDB; aqui, ordem de inserção
+	sortUsers(users, repositories.SortByCreatedAt, repositories.SortAscending)
+	return users, nil
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id string) (*entities.ProcessedUser, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repositories.ErrInvalidID, err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[objectID]
+	if !ok || !inTenant(user.TenantID, tenant) {
+		return nil, fmt.Errorf("%w: %s", repositories.ErrNotFound, id)
+	}
+	return cloneUser(user), nil
+}
+
+func (r *UserRepository) Close() error {
+	r.feed.close()
+	return nil
+}
+
+// findByDocument retorna o primeiro registro do documento no tenant; exige r.mu
+func (r *UserRepository) findByDocument(tenant, documentNumber string) *entities.ProcessedUser {
+	var found *entities.ProcessedUser
+	for _, u := range r.users {
+		if u.Document.DocumentNumber != documentNumber || !inTenant(u.TenantID, tenant) {
+			continue
+		}
+		if found == nil || compareIDs(u.ID, found.ID) < 0 {
+			found = u
+		}
+	}
+	return found
+}
+
+// changed registra a alteração na auditoria e no fluxo de alterações; exige r.mu
+// para que os eventos saiam na ordem das escritas
+func (r *UserRepository) changed(ctx context.Context, action entities.AuditAction, change repositories.ChangeType, before, after *entities.ProcessedUser) {
+	var id primitive.ObjectID
+	var current *entities.ProcessedUser
+	tenant, _ := entities.TenantFromContext(ctx)
+	switch {
+	case after != nil:
+		id = after.ID
+		current = cloneUser(after)
+	case before != nil:
+		id = before.ID
+	}
+
+	r.recordAudit(ctx, action, id, before, after)
+	r.feed.publish(tenant, repositories.UserChange{
+		Type:   change,
+		UserID: id.Hex(),
+		User:   current,
+		At:     now(),
+	})
+}
+
+func cloneUser(u *entities.ProcessedUser) *entities.ProcessedUser {
+	c := *u
+	if u.History != nil {
+		c.History = append([]entities.ProcessingRecord(nil), u.History...)
+	}
+	return &c
+}
@@ -0,0 +1,147 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+// Pesos dos campos na busca por texto, os mesmos do índice do MongoDB
+var searchWeights = []struct {
+	weight float64
+	value  func(u *entities.ProcessedUser) string
+}{
+	{10, func(u *entities.ProcessedUser) string { return u.Name }},
+	{5, func(u *entities.ProcessedUser) string { return u.Address.Street }},
+	{2, func(u *entities.ProcessedUser) string { return u.Address.City }},
+}
+
+// Search segue a estratégia do MongoDB: palavras inteiras por relevância e, se a
+// primeira página vier vazia, prefixos de palavras ordenados por nome. A busca por
+// texto aqui compara palavras sem acentos, sem os radicais do português.
+func (r *UserRepository) Search(ctx context.Context, query repositories.SearchQuery) (*repositories.UserPage, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+
+	position := repositories.SearchCursor{Mode: repositories.SearchFullText, Text: query.Text}
+	if query.Cursor != "" {
+		if position, err = repositories.DecodeSearchCursor(query.Cursor, query); err != nil {
+			return nil, err
+		}
+	}
+
+	words := strings.Fields(entities.FoldSearchText(query.Text))
+	var users []entities.ProcessedUser
+	if position.Mode == repositories.SearchFullText {
+		users = r.searchText(tenant, words)
+		if len(users) == 0 && position.Offset == 0 {
+			position.Mode = repositories.SearchPartial
+		}
+	}
+	if position.Mode == repositories.SearchPartial {
+		users = r.searchPartial(tenant, words)
+	}
+
+	if position.Offset >= len(users) {
+		users = nil
+	} else {
+		users = users[position.Offset:]
+	}
+
+	page := &repositories.UserPage{Items: users}
+	if len(users) > query.Limit {
+		page.Items = users[:query.Limit]
+		position.Offset += query.Limit
+		page.NextCursor = position.Encode()
+	}
+	return page, nil
+}
+
+func (r *UserRepository) searchText(tenant string, words []string) []entities.ProcessedUser {
+	type scored struct {
+		user  entities.ProcessedUser
+		score float64
+	}
+
+	r.mu.RLock()
+	var matches []scored
+	for _, u := range r.users {
+		// Como no MongoDB, registros anonimizados ficam fora da busca
+		if u.Status == entities.ErasedStatus || !inTenant(u.TenantID, tenant) {
+			continue
+		}
+		var score float64
+		for _, field := range searchWeights {
+			for _, token := range strings.Fields(entities.FoldSearchText(field.value(u))) {
+				for _, w := range words {
+					if token == w {
+						score += field.weight
+					}
+				}
+			}
+		}
+		if score > 0 {
+			matches = append(matches, scored{*cloneUser(u), score})
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return compareIDs(matches[i].user.ID, matches[j].user.ID) < 0
+	})
+
+	users := make([]entities.ProcessedUser, len(matches))
+	for i, m := range matches {
+		users[i] = m.user
+	}
+	return users
+}
+
+func (r *UserRepository) searchPartial(tenant string, words []string) []entities.ProcessedUser {
+	if len(words) == 0 {
+		return nil
+	}
+
+	r.mu.RLock()
+	var users []entities.ProcessedUser
+	for _, u := range r.users {
+		if u.Status == entities.ErasedStatus || !inTenant(u.TenantID, tenant) {
+			continue
+		}
+		tokens := strings.Fields(entities.SearchTextFor(u.Name, u.Address))
+		if hasAllPrefixes(tokens, words) {
+			users = append(users, *cloneUser(u))
+		}
+	}
+	r.mu.RUnlock()
+
+	sortUsers(users, repositories.SortByName, repositories.SortAscending)
+	return users
+}
+
+func hasAllPrefixes(tokens, words []string) bool {
+	for _, w := range words {
+		found := false
+		for _, t := range tokens {
+			if strings.HasPrefix(t, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+func (r *UserRepository) Stats(ctx context.Context, query repositories.StatsQuery) (*repositories.UserStats, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+	loc, _ := time.LoadLocation(query.Timezone)
+
+	inRange := func(t time.Time) bool {
+		return !t.Before(query.From) && t.Before(query.To)
+	}
+
+	byStatus := map[string]int64{}
+	byState := map[string]int64{}
+	byCity := map[[2]string]int64{}
+	volume := map[time.Time]int64{}
+
+	stats := &repositories.UserStats{
+		From:     query.From,
+		To:       query.To,
+		Interval: query.Interval,
+		Timezone: query.Timezone,
+	}
+
+	r.mu.RLock()
+	for _, u := range r.users {
+		if !inTenant(u.TenantID, tenant) {
+			continue
+		}
+		// Registros anteriores ao histórico contam como um processamento na criação
+		if len(u.History) == 0 {
+			if inRange(u.CreatedAt) {
+				volume[truncate(u.CreatedAt, query.Interval, loc)]++
+			}
+		}
+		for _, h := range u.History {
+			if inRange(h.ProcessedAt) {
+				volume[truncate(h.ProcessedAt, query.Interval, loc)]++
+			}
+		}
+
+		if !inRange(u.CreatedAt) {
+			continue
+		}
+		stats.Total++
+		byStatus[u.Status]++
+		byState[u.Address.State]++
+		byCity[[2]string{u.Address.State, u.Address.City}]++
+		if u.Document.IsValid {
+			stats.Documents.Valid++
+		} else {
+			stats.Documents.Invalid++
+		}
+	}
+	r.mu.RUnlock()
+
+	stats.ByStatus = countBuckets(byStatus)
+	stats.ByState = countBuckets(byState)
+
+	stats.ByCity = make([]repositories.CityCount, 0, len(byCity))
+	for k, n := range byCity {
+		stats.ByCity = append(stats.ByCity, repositories.CityCount{State: k[0], City: k[1], Count: n})
+	}
+	sort.Slice(stats.ByCity, func(i, j int) bool {
+		a, b := stats.ByCity[i], stats.ByCity[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.State != b.State {
+			return a.State < b.State
+		}
+		return a.City < b.City
+	})
+	if len(stats.ByCity) > repositories.MaxStatsCities {
+		stats.ByCity = stats.ByCity[:repositories.MaxStatsCities]
+	}
+
+	stats.Volume = make([]repositories.TimeBucket, 0, len(volume))
+	for start, n := range volume {
+		stats.Volume = append(stats.Volume, repositories.TimeBucket{Start: start, Count: n})
+	}
+	sort.Slice(stats.Volume, func(i, j int) bool {
+		return stats.Volume[i].Start.Before(stats.Volume[j].Start)
+	})
+
+	return stats, nil
+}
+
+// truncate início da hora ou do dia de t no fuso loc, em UTC como o $dateTrunc
+func truncate(t time.Time, interval repositories.StatsInterval, loc *time.Location) time.Time {
+	l := t.In(loc)
+	hour := l.Hour()
+	if interval == repositories.IntervalDay {
+		hour = 0
+	}
+	return time.Date(l.Year(), l.Month(), l.Day(), hour, 0, 0, 0, loc).UTC()
+}
+
+func countBuckets(counts map[string]int64) []repositories.CountBucket {
+	buckets := make([]repositories.CountBucket, 0, len(counts))
+	for k, n := range counts {
+		buckets = append(buckets, repositories.CountBucket{Key: k, Count: n})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+		return buckets[i].Key < buckets[j].Key
+	})
+	return buckets
+}
@@ -0,0 +1,179 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+const (
+	// feedHistory alterações mantidas para retomada, como o oplog do MongoDB
+	feedHistory = 1000
+	// subscriberBuffer alterações pendentes por observador antes de desconectá-lo
+	subscriberBuffer  = 256
+	resumeTokenPrefix = "mem:"
+)
+
+var errSubscriberBehind = errors.New("change stream consumer fell behind")
+
+// changeFeed distribui as alterações aos observadores e guarda as mais recentes
+type changeFeed struct {
+	mu          sync.Mutex
+	seq         int64
+	log         []feedEntry
+	subscribers map[*subscriber]struct{}
+}
+
+// feedEntry alteração e o tenant do registro alterado
+type feedEntry struct {
+	tenant string
+	change repositories.UserChange
+}
+
+type subscriber struct {
+	feed    *changeFeed
+	tenant  string
+	query   repositories.WatchQuery
+	backlog []repositories.UserChange
+	changes chan repositories.UserChange
+	// done é fechado quando o feed descarta o observador (atraso ou Close do repositório)
+	done    chan struct{}
+	dropErr error
+}
+
+func newChangeFeed() *changeFeed {
+	return &changeFeed{subscribers: make(map[*subscriber]struct{})}
+}
+
+func (f *changeFeed) publish(tenant string, change repositories.UserChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	change.ResumeToken = resumeTokenPrefix + strconv.FormatInt(f.seq, 10)
+	entry := feedEntry{tenant: tenant, change: change}
+	f.log = append(f.log, entry)
+	if len(f.log) > feedHistory {
+		f.log = f.log[len(f.log)-feedHistory:]
+	}
+
+	for s := range f.subscribers {
+		if !s.wants(entry) {
+			continue
+		}
+		select {
+		case s.changes <- change:
+		default:
+			f.drop(s, errSubscriberBehind)
+		}
+	}
+}
+
+// drop remove o observador; exige f.mu
+func (f *changeFeed) drop(s *subscriber, err error) {
+	if _, ok := f.subscribers[s]; !ok {
+		return
+	}
+	delete(f.subscribers, s)
+	s.dropErr = err
+	close(s.done)
+}
+
+func (f *changeFeed) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for s := range f.subscribers {
+		f.drop(s, errors.New("repository closed"))
+	}
+}
+
+func (r *UserRepository) Watch(ctx context.Context, query repositories.WatchQuery) (repositories.UserChangeStream, error) {
+	tenant, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f := r.feed
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s := &subscriber{
+		feed:    f,
+		tenant:  tenant,
+		query:   query,
+		changes: make(chan repositories.UserChange, subscriberBuffer),
+		done:    make(chan struct{}),
+	}
+
+	if query.ResumeToken != "" {
+		seq, err := parseResumeToken(query.ResumeToken)
+		if err != nil {
+			return nil, err
+		}
+		if seq > f.seq {
+			return nil, fmt.Errorf("%w: token is ahead of the feed", repositories.ErrInvalidResumeToken)
+		}
+		oldest := f.seq - int64(len(f.log)) + 1
+		if seq < oldest-1 {
+			return nil, repositories.ErrResumeTokenExpired
+		}
+		for _, entry := range f.log[seq-oldest+1:] {
+			if s.wants(entry) {
+				s.backlog = append(s.backlog, entry.change)
+			}
+		}
+	}
+
+	f.subscribers[s] = struct{}{}
+	return s, nil
+}
+
+// wants filtra pelo tenant do observador e pelo status consultado
+func (s *subscriber) wants(entry feedEntry) bool {
+	if !inTenant(entry.tenant, s.tenant) {
+		return false
+	}
+	if s.query.Status == "" {
+		return true
+	}
+	return entry.change.User != nil && entry.change.User.Status == s.query.Status
+}
+
+func (s *subscriber) Next(ctx context.Context) (*repositories.UserChange, error) {
+	if len(s.backlog) > 0 {
+		change := s.backlog[0]
+		s.backlog = s.backlog[1:]
+		return &change, nil
+	}
+
+	select {
+	case change := <-s.changes:
+		return &change, nil
+	case <-s.done:
+		s.feed.mu.Lock()
+		defer s.feed.mu.Unlock()
+		return nil, fmt.Errorf("change stream failed: %v", s.dropErr)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *subscriber) Close(ctx context.Context) error {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.drop(s, errors.New("change stream closed"))
+	return nil
+}
+
+func parseResumeToken(token string) (int64, error) {
+	seq, err := strconv.ParseInt(strings.TrimPrefix(token, resumeTokenPrefix), 10, 64)
+	if err != nil || !strings.HasPrefix(token, resumeTokenPrefix) || seq < 0 {
+		return 0, repositories.ErrInvalidResumeToken
+	}
+	return seq, nil
+}
@@ -0,0 +1,197 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+	"api-rabbitmq/internal/infrastructure/config"
+	"api-rabbitmq/internal/infrastructure/encryption"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	auditCollection = "user_audit"
+	// auditDocumentField dado autenticado do número do documento cifrado nos eventos
+	auditDocumentField = "user_audit.document_number"
+)
+
+type AuditRepositoryImpl struct {
+	collections tenantCollections
+	cipher      *encryption.FieldCipher
+}
+
+// NewAuditRepository cria o repositório da trilha de auditoria. Com cipher não nulo
+// os números de documento dos eventos são cifrados como na coleção de usuários. Os
+// eventos de tenants com banco dedicado ficam nesse banco (tenancy pode ser nulo).
+func NewAuditRepository(client *mongo.Client, cfg *config.DatabaseConfig, cipher *encryption.FieldCipher, tenancy *config.TenancyConfig) (repositories.AuditRepository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectionTimeout(cfg))
+	defer cancel()
+
+	collections := newTenantCollections(client, cfg, tenancy, auditCollection)
+	for _, collection := range collections.all() {
+		_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "occurred_at", Value: -1}},
+				Options: options.Index().SetName("idx_user_occurred_at"),
+			},
+			{
+				Keys: bson.D{{Key: "subject_keys", Value: 1}},
+				Options: options.Index().SetName("idx_subject_keys").
+					SetPartialFilterExpression(bson.M{"subject_keys": bson.M{"$exists": true}}),
+			},
+			// Eventos gravados antes de subject_keys guardam um único subject_key
+			{
+				Keys: bson.D{{Key: "subject_key", Value: 1}},
+				Options: options.Index().SetName("idx_subject_key").
+					SetPartialFilterExpression(bson.M{"subject_key": bson.M{"$exists": true}}),
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create audit indexes on %s: %v", collection.Database().Name(), err)
+		}
+	}
+
+	return &AuditRepositoryImpl{collections: collections, cipher: cipher}, nil
+}
+
+func (r *AuditRepositoryImpl) Append(ctx context.Context, event *entities.AuditEvent) error {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	event.TenantID = s.tenant
+	stored := *event
+	stored.Changes = make([]entities.FieldChange, len(event.Changes))
+	for i, change := range event.Changes {
+		sealed, err := r.sealChange(change)
+		if err != nil {
+			return err
+		}
+		stored.Changes[i] = sealed
+	}
+
+	result, err := s.collection.InsertOne(ctx, stored)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit event: %v", err)
+	}
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		event.ID = oid
+	}
+	return nil
+}
+
+func (r *AuditRepositoryImpl) FindByUser(ctx context.Context, userID string, limit int) ([]entities.AuditEvent, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, fmt.Errorf("%w: %v", repositories.ErrInvalidID, err)
+	}
+	if limit <= 0 {
+		limit = repositories.DefaultHistoryLimit
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "occurred_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := s.collection.Find(ctx, s.match(bson.M{"user_id": userID}), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit events: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	events := []entities.AuditEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode audit events: %v", err)
+	}
+
+	for i := range events {
+		for j, change := range events[i].Changes {
+			opened, err := r.openChange(change)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt audit event %s: %v", events[i].ID.Hex(), err)
+			}
+			events[i].Changes[j] = opened
+		}
+	}
+	return events, nil
+}
+
+func (r *AuditRepositoryImpl) Name() string {
+	return r.collections.shared.Name()
+}
+
+// EraseSubject remove os eventos do titular ou, na anonimização, mantém apenas o
+// esqueleto do evento (ação, ator, versão e data) sem os valores alterados
+func (r *AuditRepositoryImpl) EraseSubject(ctx context.Context, documentNumber string, mode repositories.ErasureMode) (int64, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return 0, err
+	}
+	key := subjectKey(r.cipher, documentNumber)
+	filter := s.match(bson.M{"$or": bson.A{bson.M{"subject_keys": key}, bson.M{"subject_key": key}}})
+
+	if mode == repositories.ErasureDelete {
+		result, err := s.collection.DeleteMany(ctx, filter)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete subject audit events: %v", err)
+		}
+		return result.DeletedCount, nil
+	}
+
+	result, err := s.collection.UpdateMany(ctx, filter, bson.M{
+		"$set":   bson.M{"redacted": true},
+		"$unset": bson.M{"changes": "", "subject_keys": "", "subject_key": ""},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to redact subject audit events: %v", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *AuditRepositoryImpl) sealChange(change entities.FieldChange) (entities.FieldChange, error) {
+	if r.cipher == nil || change.Field != documentNumberField {
+		return change, nil
+	}
+
+	var err error
+	for _, v := range []*interface{}{&change.Before, &change.After} {
+		s, ok := (*v).(string)
+		if !ok || s == "" {
+			continue
+		}
+		if *v, err = r.cipher.Encrypt(auditDocumentField, s); err != nil {
+			return change, fmt.Errorf("failed to encrypt audit document number: %v", err)
+		}
+	}
+	return change, nil
+}
+
+func (r *AuditRepositoryImpl) openChange(change entities.FieldChange) (entities.FieldChange, error) {
+	if change.Field != documentNumberField {
+		return change, nil
+	}
+
+	var err error
+	for _, v := range []*interface{}{&change.Before, &change.After} {
+		s, ok := (*v).(string)
+		if !ok || !encryption.IsEncrypted(s) {
+			continue
+		}
+		if r.cipher == nil {
+			return change, fmt.Errorf("encrypted value found but encryption is not configured")
+		}
+		if *v, err = r.cipher.Decrypt(auditDocumentField, s); err != nil {
+			return change, err
+		}
+	}
+	return change, nil
+}
@@ -0,0 +1,113 @@
+package mongodb
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"api-rabbitmq/internal/infrastructure/config"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultConnectionTimeout = 10 * time.Second
+
+// Connect abre o cliente do MongoDB configurado por cfg e valida a conexão com ping
+func Connect(cfg *config.DatabaseConfig) (*mongo.Client, error) {
+	clientOptions, err := clientOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectionTimeout(cfg))
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %v", err)
+	}
+
+	err = client.Ping(ctx, nil)
+	if err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("failed to ping MongoDB: %v", err)
+	}
+
+	log.Println("Successfully connected to MongoDB")
+	return client, nil
+}
+
+func connectionTimeout(cfg *config.DatabaseConfig) time.Duration {
+	if cfg.ConnectionTimeout <= 0 {
+		return defaultConnectionTimeout
+	}
+	return cfg.ConnectionTimeout
+}
+
+// clientOptions monta as opções do driver a partir de DatabaseConfig. Valores
+// explícitos da configuração prevalecem sobre os definidos na URI.
+func clientOptions(cfg *config.DatabaseConfig) (*options.ClientOptions, error) {
+	opts := options.Client().
+		ApplyURI(cfg.GetConnectionString()).
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetMinPoolSize(cfg.MinPoolSize)
+
+	if cfg.ConnectionTimeout > 0 {
+		opts.SetConnectTimeout(cfg.ConnectionTimeout).
+			SetServerSelectionTimeout(cfg.ConnectionTimeout)
+	}
+
+	if cfg.SSL {
+		tlsConfig, err := tlsConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	return opts, nil
+}
+
+func tlsConfig(cfg *config.DatabaseConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.TLSInsecure,
+	}
+
+	if cfg.TLSCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MongoDB CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in MongoDB CA file %s", cfg.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load MongoDB client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Ping retorna uma verificação de saúde que consulta o MongoDB com a read preference do cliente
+func Ping(client *mongo.Client) func(ctx context.Context) (any, error) {
+	return func(ctx context.Context) (any, error) {
+		if err := client.Ping(ctx, nil); err != nil {
+			return nil, fmt.Errorf("failed to ping MongoDB: %v", err)
+		}
+		return nil, nil
+	}
+}
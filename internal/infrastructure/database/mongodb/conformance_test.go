@@ -0,0 +1,80 @@
+package mongodb_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/infrastructure/config"
+	"api-rabbitmq/internal/infrastructure/database/mongodb"
+	"api-rabbitmq/internal/infrastructure/database/repotest"
+)
+
+// TestConformance exige um MongoDB em MONGODB_URI (os cenários de Watch, um replica
+// set, como o do docker-compose). Cada cenário usa bancos próprios, removidos ao final.
+func TestConformance(t *testing.T) {
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		t.Skip("MONGODB_URI is not set")
+	}
+
+	repotest.Run(t, func(t *testing.T, opts repotest.Options) repotest.Repositories {
+		cfg := &config.DatabaseConfig{
+			URI:               uri,
+			DatabaseName:      "repotest_" + primitive.NewObjectID().Hex(),
+			CollectionName:    "processed_users",
+			ConnectionTimeout: 10 * time.Second,
+			DedupePolicy:      string(opts.DedupePolicy),
+		}
+		client, err := mongodb.Connect(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		audit, err := mongodb.NewAuditRepository(client, cfg, nil, opts.Tenancy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		users, err := mongodb.NewUserRepository(client, cfg, mongodb.UserRepositoryOptions{Audit: audit, Tenancy: opts.Tenancy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			ctx := context.Background()
+			databases := []string{cfg.DatabaseName}
+			if opts.Tenancy != nil {
+				for _, tenant := range opts.Tenancy.All() {
+					if opts.Tenancy.HasDedicatedDatabase(tenant) {
+						databases = append(databases, opts.Tenancy.DatabaseName(cfg.DatabaseName, tenant))
+					}
+				}
+			}
+			for _, name := range databases {
+				if err := client.Database(name).Drop(ctx); err != nil {
+					t.Errorf("failed to drop %s: %v", name, err)
+				}
+			}
+			users.Close()
+		})
+
+		return repotest.Repositories{
+			Users: users,
+			Audit: audit,
+			InsertLegacy: func(t *testing.T, user *entities.ProcessedUser) {
+				at := time.Now().UTC().Truncate(time.Millisecond)
+				user.ID = primitive.NewObjectID()
+				user.TenantID = ""
+				user.CreatedAt, user.UpdatedAt, user.Version = at, at, 1
+
+				collection := client.Database(cfg.DatabaseName).Collection(cfg.CollectionName)
+				if _, err := collection.InsertOne(context.Background(), user); err != nil {
+					t.Fatal(err)
+				}
+			},
+		}
+	})
+}
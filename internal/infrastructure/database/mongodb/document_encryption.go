@@ -0,0 +1,76 @@
+package mongodb
+
+import (
+	"fmt"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/infrastructure/encryption"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const documentNumberField = "document.document_number"
+
+// documentFilter seleciona os registros de um documento: pelo índice cego quando a
+// criptografia está ativa, ou pelo número normalizado caso contrário
+func (r *UserRepositoryImpl) documentFilter(documentNumber string) bson.M {
+	if r.cipher != nil {
+		return bson.M{"document.document_hash": subjectKey(r.cipher, documentNumber)}
+	}
+	return bson.M{documentNumberField: subjectKey(nil, documentNumber)}
+}
+
+// subjectKey identifica o titular de um documento sem expor o número quando a
+// criptografia está ativa (índice cego); sem ela, é o número normalizado
+func subjectKey(cipher *encryption.FieldCipher, documentNumber string) string {
+	normalized := entities.NormalizeDocumentNumber(documentNumber)
+	if cipher != nil {
+		return cipher.BlindIndex(normalized)
+	}
+	return normalized
+}
+
+// sealDocument normaliza o número do documento e, com a criptografia ativa, cifra
+// o número e preenche o índice cego
+func (r *UserRepositoryImpl) sealDocument(doc entities.DocumentUserProcessed) (entities.DocumentUserProcessed, error) {
+	doc.DocumentNumber = entities.NormalizeDocumentNumber(doc.DocumentNumber)
+	doc.DocumentHash = ""
+	if r.cipher == nil {
+		return doc, nil
+	}
+
+	encrypted, err := r.cipher.Encrypt(documentNumberField, doc.DocumentNumber)
+	if err != nil {
+		return doc, fmt.Errorf("failed to encrypt document number: %v", err)
+	}
+	doc.DocumentHash = r.cipher.BlindIndex(doc.DocumentNumber)
+	doc.DocumentNumber = encrypted
+	return doc, nil
+}
+
+// openUser decifra o número do documento de um registro lido do banco
+func (r *UserRepositoryImpl) openUser(user *entities.ProcessedUser) error {
+	user.Document.DocumentHash = ""
+	if !encryption.IsEncrypted(user.Document.DocumentNumber) {
+		return nil
+	}
+	if r.cipher == nil {
+		return fmt.Errorf("user %s has an encrypted document number but encryption is not configured", user.ID.Hex())
+	}
+
+	plaintext, err := r.cipher.Decrypt(documentNumberField, user.Document.DocumentNumber)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt user %s: %v", user.ID.Hex(), err)
+	}
+	user.Document.DocumentNumber = plaintext
+	return nil
+}
+
+func (r *UserRepositoryImpl) openUsers(users []entities.ProcessedUser) error {
+	for i := range users {
+		if err := r.openUser(&users[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
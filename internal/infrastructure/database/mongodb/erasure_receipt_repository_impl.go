@@ -0,0 +1,90 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+	"api-rabbitmq/internal/infrastructure/config"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	erasureReceiptsCollection = "erasure_receipts"
+	appendRetries             = 3
+)
+
+type ErasureReceiptRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewErasureReceiptRepository(client *mongo.Client, cfg *config.DatabaseConfig) (repositories.ErasureReceiptRepository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectionTimeout(cfg))
+	defer cancel()
+
+	collection := client.Database(cfg.DatabaseName).Collection(erasureReceiptsCollection)
+
+	// A sequência única impede que duas gravações concorrentes bifurquem a cadeia
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "sequence", Value: 1}},
+		Options: options.Index().SetName("uniq_sequence").SetUnique(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create erasure receipt index: %v", err)
+	}
+
+	return &ErasureReceiptRepositoryImpl{collection: collection}, nil
+}
+
+func (r *ErasureReceiptRepositoryImpl) Append(ctx context.Context, receipt *entities.ErasureReceipt) error {
+	for attempt := 0; attempt < appendRetries; attempt++ {
+		var last entities.ErasureReceipt
+		err := r.collection.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"sequence": -1})).Decode(&last)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("failed to read last erasure receipt: %v", err)
+		}
+
+		receipt.Sequence = last.Sequence + 1
+		receipt.PreviousHash = last.Hash
+		receipt.Hash = receipt.ComputeHash()
+
+		result, err := r.collection.InsertOne(ctx, receipt)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to insert erasure receipt: %v", err)
+		}
+
+		if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+			receipt.ID = oid
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to append erasure receipt after %d attempts", appendRetries)
+}
+
+func (r *ErasureReceiptRepositoryImpl) ForEach(ctx context.Context, fn func(receipt *entities.ErasureReceipt) error) error {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"sequence": 1}))
+	if err != nil {
+		return fmt.Errorf("failed to find erasure receipts: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var receipt entities.ErasureReceipt
+		if err := cursor.Decode(&receipt); err != nil {
+			return fmt.Errorf("failed to decode erasure receipt: %v", err)
+		}
+		if err := fn(&receipt); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
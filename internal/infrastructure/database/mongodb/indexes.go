@@ -0,0 +1,352 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IndexDrift diferenças entre os índices declarados pelo repositório e os existentes na collection
+type IndexDrift struct {
+	Missing    []string `json:"missing"`
+	Unexpected []string `json:"unexpected"`
+	Mismatched []string `json:"mismatched"`
+	// TTLMismatched índices com mesmas chaves, mas expireAfterSeconds diferente do declarado
+	TTLMismatched []string `json:"ttl_mismatched"`
+}
+
+// HasDrift indica se há alguma diferença
+func (d IndexDrift) HasDrift() bool {
+	return len(d.Missing) > 0 || len(d.Unexpected) > 0 || len(d.Mismatched) > 0 || len(d.TTLMismatched) > 0
+}
+
+// declaredIndexes índices que a collection processed_users deve ter
+func (r *UserRepositoryImpl) declaredIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		r.documentIndex(),
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}},
+			Options: options.Index().SetName("idx_status"),
+		},
+		r.createdAtIndex(),
+		{
+			// Estatísticas: registros reprocessados no período
+			Keys:    bson.D{{Key: "updated_at", Value: 1}},
+			Options: options.Index().SetName("idx_updated_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "address.state", Value: 1}},
+			Options: options.Index().SetName("idx_address_state"),
+		},
+		textSearchIndex(),
+		{
+			// Busca por prefixo (ver searchPartial)
+			Keys:    bson.D{{Key: tenantField, Value: 1}, {Key: "search_tokens", Value: 1}},
+			Options: options.Index().SetName("idx_tenant_search_tokens"),
+		},
+	}
+}
+
+// textSearchIndex índice de texto da busca. O idioma português aplica radicais e
+// stop words; a versão 3 do índice já ignora acentos e maiúsculas.
+func textSearchIndex() mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "name", Value: "text"},
+			{Key: "address.street", Value: "text"},
+			{Key: "address.city", Value: "text"},
+		},
+		Options: options.Index().
+			SetName("idx_text_search").
+			SetDefaultLanguage("portuguese").
+			SetWeights(bson.D{
+				{Key: "name", Value: 10},
+				{Key: "address.street", Value: 5},
+				{Key: "address.city", Value: 2},
+			}),
+	}
+}
+
+// documentIndex índice usado na deduplicação, por tenant: sobre o índice cego quando
+// a criptografia está ativa, senão sobre o número do documento. É único, exceto na
+// política DedupeInsert, que aceita duplicados.
+func (r *UserRepositoryImpl) documentIndex() mongo.IndexModel {
+	unique := r.dedupePolicy != repositories.DedupeInsert
+
+	if r.cipher != nil {
+		// Registros anonimizados ou anteriores à criptografia não têm o índice cego
+		return mongo.IndexModel{
+			Keys: bson.D{{Key: tenantField, Value: 1}, {Key: "document.document_hash", Value: 1}},
+			Options: options.Index().
+				SetName("idx_tenant_document_hash").
+				SetUnique(unique).
+				SetPartialFilterExpression(bson.M{"document.document_hash": bson.M{"$exists": true}}),
+		}
+	}
+
+	return mongo.IndexModel{
+		Keys: bson.D{{Key: tenantField, Value: 1}, {Key: "document.document_number", Value: 1}},
+		Options: options.Index().
+			SetName("idx_tenant_document_number").
+			SetUnique(unique),
+	}
+}
+
+// createdAtIndex índice de created_at; com retenção por TTL, o MongoDB remove os
+// registros expirados a partir dele
+func (r *UserRepositoryImpl) createdAtIndex() mongo.IndexModel {
+	opts := options.Index().SetName("idx_created_at")
+	if r.retentionTTL > 0 {
+		opts.SetExpireAfterSeconds(int32(r.retentionTTL.Seconds()))
+	}
+	return mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: opts,
+	}
+}
+
+// EnsureIndexes cria os índices declarados que ainda não existem e ajusta o TTL dos
+// existentes, na collection compartilhada e nas dos bancos dedicados
+func (r *UserRepositoryImpl) EnsureIndexes(ctx context.Context) error {
+	for _, collection := range r.collections.all() {
+		if err := r.ensureIndexes(ctx, collection); err != nil {
+			return fmt.Errorf("%s.%s: %w", collection.Database().Name(), collection.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (r *UserRepositoryImpl) ensureIndexes(ctx context.Context, collection *mongo.Collection) error {
+	drift, err := r.indexDrift(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	// O índice de documento é recriado: sem a unicidade, upsert e reject não impedem
+	// duplicados em inserções concorrentes
+	documentIndexName := *r.documentIndex().Options.Name
+	if i := slices.Index(drift.Mismatched, documentIndexName); i >= 0 {
+		log.Printf("Rebuilding index %s on %s to match the %s dedupe policy", documentIndexName, collection.Database().Name(), r.dedupePolicy)
+		if _, err := collection.Indexes().DropOne(ctx, documentIndexName); err != nil {
+			return fmt.Errorf("failed to drop index %s: %v", documentIndexName, err)
+		}
+		drift.Mismatched = slices.Delete(drift.Mismatched, i, i+1)
+		drift.Missing = append(drift.Missing, documentIndexName)
+	}
+
+	// Os demais índices divergentes não são recriados automaticamente: exigem intervenção manual
+	if len(drift.Unexpected) > 0 || len(drift.Mismatched) > 0 {
+		log.Printf("Warning: MongoDB index drift on %s: unexpected=%v mismatched=%v",
+			collection.Database().Name(), drift.Unexpected, drift.Mismatched)
+	}
+	if err := r.syncTTL(ctx, collection, drift.TTLMismatched); err != nil {
+		return err
+	}
+	if len(drift.Missing) == 0 {
+		return nil
+	}
+
+	missing := make(map[string]bool, len(drift.Missing))
+	for _, name := range drift.Missing {
+		missing[name] = true
+	}
+
+	var models []mongo.IndexModel
+	for _, model := range r.declaredIndexes() {
+		if missing[*model.Options.Name] {
+			models = append(models, model)
+		}
+	}
+
+	log.Printf("Creating MongoDB indexes on %s: %v", collection.Database().Name(), drift.Missing)
+	if _, err := collection.Indexes().CreateMany(ctx, models); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// A mensagem do servidor repete o documento duplicado; não deve chegar aos logs
+			return fmt.Errorf("failed to create indexes: records with the same document exist; run \"migrate up\" to merge them")
+		}
+		return fmt.Errorf("failed to create indexes: %v", err)
+	}
+	return nil
+}
+
+// IndexDrift compara os índices declarados com os existentes (por nome e chaves) na
+// collection do tenant de ctx
+func (r *UserRepositoryImpl) IndexDrift(ctx context.Context) (IndexDrift, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return IndexDrift{}, err
+	}
+	return r.indexDrift(ctx, s.collection)
+}
+
+func (r *UserRepositoryImpl) indexDrift(ctx context.Context, collection *mongo.Collection) (IndexDrift, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return IndexDrift{}, fmt.Errorf("failed to list indexes: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var existing []struct {
+		Name               string `bson:"name"`
+		Key                bson.D `bson:"key"`
+		Unique             bool   `bson:"unique"`
+		ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+		Weights            bson.M `bson:"weights"`
+		DefaultLanguage    string `bson:"default_language"`
+	}
+	if err := cursor.All(ctx, &existing); err != nil {
+		return IndexDrift{}, fmt.Errorf("failed to decode indexes: %v", err)
+	}
+
+	existingByName := make(map[string]int, len(existing))
+	for i, idx := range existing {
+		existingByName[idx.Name] = i
+	}
+
+	drift := IndexDrift{}
+	declaredNames := make(map[string]bool)
+	for _, model := range r.declaredIndexes() {
+		name := *model.Options.Name
+		declaredNames[name] = true
+
+		i, ok := existingByName[name]
+		if !ok {
+			drift.Missing = append(drift.Missing, name)
+			continue
+		}
+
+		if isTextIndex(model) {
+			// O servidor guarda índices de texto como {_fts, _ftsx}; os campos ficam em weights
+			if !sameTextIndex(model, existing[i].Weights, existing[i].DefaultLanguage) {
+				drift.Mismatched = append(drift.Mismatched, name)
+			}
+			continue
+		}
+
+		unique := model.Options.Unique != nil && *model.Options.Unique
+		if !sameKeys(model.Keys.(bson.D), existing[i].Key) || unique != existing[i].Unique {
+			drift.Mismatched = append(drift.Mismatched, name)
+			continue
+		}
+
+		if !sameTTL(declaredTTL(model), existing[i].ExpireAfterSeconds) {
+			drift.TTLMismatched = append(drift.TTLMismatched, name)
+		}
+	}
+
+	for _, idx := range existing {
+		if idx.Name != "_id_" && !declaredNames[idx.Name] {
+			drift.Unexpected = append(drift.Unexpected, idx.Name)
+		}
+	}
+
+	return drift, nil
+}
+
+// syncTTL aplica o expireAfterSeconds declarado aos índices existentes via collMod
+// (MongoDB 5.1+ também converte um índice comum em TTL). Remover o TTL exige
+// recriar o índice, então nesse caso apenas avisa.
+func (r *UserRepositoryImpl) syncTTL(ctx context.Context, collection *mongo.Collection, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	pending := make(map[string]bool, len(names))
+	for _, name := range names {
+		pending[name] = true
+	}
+
+	for _, model := range r.declaredIndexes() {
+		name := *model.Options.Name
+		if !pending[name] {
+			continue
+		}
+
+		ttl := declaredTTL(model)
+		if ttl == nil {
+			log.Printf("Warning: index %s has a TTL but retention by TTL is disabled; drop the index to stop expiring documents", name)
+			continue
+		}
+
+		log.Printf("Setting expireAfterSeconds=%d on index %s", *ttl, name)
+		err := collection.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collection.Name()},
+			{Key: "index", Value: bson.D{{Key: "name", Value: name}, {Key: "expireAfterSeconds", Value: *ttl}}},
+		}).Err()
+		if err != nil {
+			return fmt.Errorf("failed to update TTL of index %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func declaredTTL(model mongo.IndexModel) *int64 {
+	if model.Options.ExpireAfterSeconds == nil {
+		return nil
+	}
+	ttl := int64(*model.Options.ExpireAfterSeconds)
+	return &ttl
+}
+
+func sameTTL(declared, existing *int64) bool {
+	if declared == nil || existing == nil {
+		return declared == nil && existing == nil
+	}
+	return *declared == *existing
+}
+
+func isTextIndex(model mongo.IndexModel) bool {
+	for _, k := range model.Keys.(bson.D) {
+		if k.Value == "text" {
+			return true
+		}
+	}
+	return false
+}
+
+// sameTextIndex compara campos, pesos e idioma de um índice de texto
+func sameTextIndex(model mongo.IndexModel, weights bson.M, language string) bool {
+	declared, _ := model.Options.Weights.(bson.D)
+	if len(declared) != len(weights) {
+		return false
+	}
+	for _, w := range declared {
+		if fmt.Sprint(toFloat(w.Value)) != fmt.Sprint(toFloat(weights[w.Key])) {
+			return false
+		}
+	}
+	return model.Options.DefaultLanguage != nil && *model.Options.DefaultLanguage == language
+}
+
+// sameKeys compara especificações de chave ignorando o tipo numérico (int32/int64/double)
+func sameKeys(declared, existing bson.D) bool {
+	if len(declared) != len(existing) {
+		return false
+	}
+	for i := range declared {
+		if declared[i].Key != existing[i].Key ||
+			fmt.Sprint(toFloat(declared[i].Value)) != fmt.Sprint(toFloat(existing[i].Value)) {
+			return false
+		}
+	}
+	return true
+}
+
+func toFloat(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return v
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// backfillVersioning preenche version e updated_at nos registros gravados antes do
+// controle de concorrência otimista. Registros sem version equivalem à versão 0,
+// então a migração não invalida ETags já entregues.
+func backfillVersioning() Migration {
+	return Migration{
+		Version:     1,
+		Description: "backfill version and updated_at on legacy documents",
+		Up: func(ctx context.Context, users *mongo.Collection) error {
+			if _, err := users.UpdateMany(ctx,
+				bson.M{"version": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"version": int64(0)}},
+			); err != nil {
+				return fmt.Errorf("failed to backfill version: %v", err)
+			}
+
+			_, err := users.UpdateMany(ctx,
+				bson.M{"updated_at": bson.M{"$exists": false}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.M{"updated_at": "$created_at"}}}},
+			)
+			if err != nil {
+				return fmt.Errorf("failed to backfill updated_at: %v", err)
+			}
+			return nil
+		},
+		// Os valores preenchidos são equivalentes à ausência dos campos
+		Down: func(ctx context.Context, users *mongo.Collection) error {
+			return nil
+		},
+	}
+}
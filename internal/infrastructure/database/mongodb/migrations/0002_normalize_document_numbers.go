@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"api-rabbitmq/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// normalizeDocumentNumbers remove a pontuação dos números de documento gravados
+// antes da normalização, para que a deduplicação e a busca por documento os
+// encontrem. Valores cifrados ou anonimizados não são alterados. Irreversível: a
+// formatação original não é guardada.
+func normalizeDocumentNumbers() Migration {
+	return Migration{
+		Version:     2,
+		Description: "normalize plaintext document numbers to digits only",
+		Up: func(ctx context.Context, users *mongo.Collection) error {
+			filter := bson.M{"$and": bson.A{
+				bson.M{"document.document_number": primitive.Regex{Pattern: `\D`}},
+				bson.M{"document.document_number": bson.M{"$not": primitive.Regex{Pattern: `^(enc|erased):`}}},
+			}}
+
+			cursor, err := users.Find(ctx, filter)
+			if err != nil {
+				return fmt.Errorf("failed to find unnormalized documents: %v", err)
+			}
+			defer cursor.Close(ctx)
+
+			for cursor.Next(ctx) {
+				var user struct {
+					ID       primitive.ObjectID `bson:"_id"`
+					Document struct {
+						DocumentNumber string `bson:"document_number"`
+					} `bson:"document"`
+				}
+				if err := cursor.Decode(&user); err != nil {
+					return fmt.Errorf("failed to decode user: %v", err)
+				}
+
+				normalized := entities.NormalizeDocumentNumber(user.Document.DocumentNumber)
+				_, err := users.UpdateByID(ctx, user.ID, bson.M{"$set": bson.M{"document.document_number": normalized}})
+				if mongo.IsDuplicateKeyError(err) {
+					// O valor não vai para a mensagem: é dado pessoal
+					return fmt.Errorf("user %s duplicates another record once normalized; merge or erase one of them and rerun", user.ID.Hex())
+				}
+				if err != nil {
+					return fmt.Errorf("failed to normalize user %s: %v", user.ID.Hex(), err)
+				}
+			}
+			if err := cursor.Err(); err != nil {
+				return fmt.Errorf("failed to iterate users: %v", err)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"api-rabbitmq/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// backfillSearchText preenche search_text, usado pela busca parcial, nos registros
+// gravados antes da busca. Registros anonimizados ficam de fora da busca.
+func backfillSearchText() Migration {
+	return Migration{
+		Version:     3,
+		Description: "backfill search_text for partial search",
+		Up: func(ctx context.Context, users *mongo.Collection) error {
+			filter := bson.M{
+				"search_text": bson.M{"$exists": false},
+				"status":      bson.M{"$ne": entities.ErasedStatus},
+			}
+			projection := bson.M{"name": 1, "address": 1}
+
+			cursor, err := users.Find(ctx, filter, options.Find().SetProjection(projection))
+			if err != nil {
+				return fmt.Errorf("failed to find users without search_text: %v", err)
+			}
+			defer cursor.Close(ctx)
+
+			for cursor.Next(ctx) {
+				var user struct {
+					ID      primitive.ObjectID       `bson:"_id"`
+					Name    string                   `bson:"name"`
+					Address entities.AddressResponse `bson:"address"`
+				}
+				if err := cursor.Decode(&user); err != nil {
+					return fmt.Errorf("failed to decode user: %v", err)
+				}
+
+				_, err := users.UpdateByID(ctx, user.ID, bson.M{"$set": bson.M{
+					"search_text": entities.SearchTextFor(user.Name, user.Address),
+				}})
+				if err != nil {
+					return fmt.Errorf("failed to set search_text on user %s: %v", user.ID.Hex(), err)
+				}
+			}
+			if err := cursor.Err(); err != nil {
+				return fmt.Errorf("failed to iterate users: %v", err)
+			}
+			return nil
+		},
+		Down: func(ctx context.Context, users *mongo.Collection) error {
+			if _, err := users.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"search_text": ""}}); err != nil {
+				return fmt.Errorf("failed to remove search_text: %v", err)
+			}
+			return nil
+		},
+	}
+}
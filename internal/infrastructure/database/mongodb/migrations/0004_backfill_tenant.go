@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"api-rabbitmq/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// codeIndexNotFound erro do servidor ao remover um índice inexistente
+const codeIndexNotFound = 27
+
+// backfillTenant atribui os registros gravados antes da multi-tenancy ao tenant do
+// banco (o padrão, no compartilhado) e remove os índices únicos de documento sem tenant, substituídos
+// pelos índices por tenant que o repositório cria. Irreversível: depois dela, o
+// mesmo documento pode existir em mais de um tenant.
+func backfillTenant() Migration {
+	return Migration{
+		Version:     4,
+		Description: "assign legacy documents to the default tenant",
+		Up: func(ctx context.Context, users *mongo.Collection) error {
+			tenant, ok := entities.TenantFromContext(ctx)
+			if !ok {
+				tenant = entities.DefaultTenant
+			}
+			_, err := users.UpdateMany(ctx,
+				bson.M{"tenant_id": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"tenant_id": tenant}},
+			)
+			if err != nil {
+				return fmt.Errorf("failed to backfill tenant_id: %v", err)
+			}
+
+			for _, name := range []string{"idx_document_number", "idx_document_hash"} {
+				_, err := users.Indexes().DropOne(ctx, name)
+				var cmdErr mongo.CommandError
+				if errors.As(err, &cmdErr) && cmdErr.Code == codeIndexNotFound {
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("failed to drop index %s: %v", name, err)
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,143 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mergeDuplicateDocuments funde os registros do mesmo documento no mesmo tenant,
+// gravados antes da deduplicação, para que o índice único de documento possa ser
+// criado. Como no upsert, fica o registro mais antigo (ID e created_at), com os dados
+// do processamento mais recente e os históricos de todos. Os eventos de auditoria dos
+// registros removidos são mantidos. Irreversível.
+func mergeDuplicateDocuments() Migration {
+	return Migration{
+		Version:     5,
+		Description: "merge duplicate records of the same document per tenant",
+		Up: func(ctx context.Context, users *mongo.Collection) error {
+			// Com a criptografia ativa o número cifrado varia a cada gravação; o índice
+			// cego identifica o documento
+			pipeline := mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"document.document_number": bson.M{"$not": primitive.Regex{Pattern: `^erased:`}}}}},
+				{{Key: "$group", Value: bson.M{
+					"_id": bson.M{
+						"tenant": "$tenant_id",
+						"key":    bson.M{"$ifNull": bson.A{"$document.document_hash", "$document.document_number"}},
+					},
+					"ids":   bson.M{"$push": "$_id"},
+					"count": bson.M{"$sum": 1},
+				}}},
+				{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+			}
+
+			cursor, err := users.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+			if err != nil {
+				return fmt.Errorf("failed to find duplicate documents: %v", err)
+			}
+			defer cursor.Close(ctx)
+
+			merged := 0
+			for cursor.Next(ctx) {
+				var group struct {
+					IDs []primitive.ObjectID `bson:"ids"`
+				}
+				if err := cursor.Decode(&group); err != nil {
+					return fmt.Errorf("failed to decode duplicate group: %v", err)
+				}
+				if err := mergeUsers(ctx, users, group.IDs); err != nil {
+					return err
+				}
+				merged++
+			}
+			if err := cursor.Err(); err != nil {
+				return fmt.Errorf("failed to iterate duplicate documents: %v", err)
+			}
+			if merged > 0 {
+				log.Printf("Merged %d duplicated documents", merged)
+			}
+			return nil
+		},
+	}
+}
+
+// mergeUsers funde ids no registro mais antigo. Idempotente: se a remoção dos demais
+// for interrompida, a nova execução não duplica o histórico.
+func mergeUsers(ctx context.Context, users *mongo.Collection, ids []primitive.ObjectID) error {
+	cursor, err := users.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return fmt.Errorf("failed to read duplicate users: %v", err)
+	}
+	var records []entities.ProcessedUser
+	if err := cursor.All(ctx, &records); err != nil {
+		return fmt.Errorf("failed to decode duplicate users: %v", err)
+	}
+	if len(records) < 2 {
+		return nil
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].CreatedAt.Before(records[j].CreatedAt)
+		}
+		return records[i].ID.Hex() < records[j].ID.Hex()
+	})
+	survivor := records[0]
+
+	latest := survivor
+	var version int64
+	var history []entities.ProcessingRecord
+	seen := make(map[string]bool)
+	for _, u := range records {
+		if u.UpdatedAt.After(latest.UpdatedAt) {
+			latest = u
+		}
+		version = max(version, u.Version)
+		for _, h := range u.History {
+			key := fmt.Sprintf("%d|%s|%s", h.ProcessedAt.UnixNano(), h.Status, h.Message)
+			if !seen[key] {
+				seen[key] = true
+				history = append(history, h)
+			}
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool { return history[i].ProcessedAt.Before(history[j].ProcessedAt) })
+	if n := len(history); n > repositories.MaxHistoryRecords {
+		history = history[n-repositories.MaxHistoryRecords:]
+	}
+
+	set := bson.M{
+		"name":       latest.Name,
+		"document":   latest.Document,
+		"address":    latest.Address,
+		"status":     latest.Status,
+		"message":    latest.Message,
+		"updated_at": latest.UpdatedAt,
+		"history":    history,
+		// Nova versão: ETags dos registros fundidos deixam de valer
+		"version": version + 1,
+		// Formato da busca até a versão 6
+		"search_text": entities.SearchTextFor(latest.Name, latest.Address),
+	}
+	if _, err := users.UpdateByID(ctx, survivor.ID, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("failed to merge into user %s: %v", survivor.ID.Hex(), err)
+	}
+
+	others := make([]primitive.ObjectID, 0, len(records)-1)
+	for _, u := range records[1:] {
+		others = append(others, u.ID)
+	}
+	if _, err := users.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": others}}); err != nil {
+		return fmt.Errorf("failed to remove users merged into %s: %v", survivor.ID.Hex(), err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"api-rabbitmq/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// searchTokens troca search_text por search_tokens: a busca parcial passa a ser por
+// prefixo de palavra, atendida pelo índice idx_tenant_search_tokens em vez de uma
+// expressão regular sobre o texto inteiro.
+func searchTokens() Migration {
+	return Migration{
+		Version:     6,
+		Description: "replace search_text with indexed search_tokens",
+		Up: func(ctx context.Context, users *mongo.Collection) error {
+			err := rewriteSearchField(ctx, users, "search_tokens", "search_text", func(name string, address entities.AddressResponse) interface{} {
+				return entities.SearchTokensFor(name, address)
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := users.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"search_text": ""}}); err != nil {
+				return fmt.Errorf("failed to remove search_text: %v", err)
+			}
+			return nil
+		},
+		Down: func(ctx context.Context, users *mongo.Collection) error {
+			err := rewriteSearchField(ctx, users, "search_text", "search_tokens", func(name string, address entities.AddressResponse) interface{} {
+				return entities.SearchTextFor(name, address)
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := users.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"search_tokens": ""}}); err != nil {
+				return fmt.Errorf("failed to remove search_tokens: %v", err)
+			}
+			return nil
+		},
+	}
+}
+
+// rewriteSearchField grava field, calculado de nome e endereço, e remove old nos
+// registros que ainda não têm field. Registros anonimizados ficam de fora da busca.
+func rewriteSearchField(ctx context.Context, users *mongo.Collection, field, old string, value func(string, entities.AddressResponse) interface{}) error {
+	filter := bson.M{
+		field:    bson.M{"$exists": false},
+		"status": bson.M{"$ne": entities.ErasedStatus},
+	}
+	projection := bson.M{"name": 1, "address": 1}
+
+	cursor, err := users.Find(ctx, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return fmt.Errorf("failed to find users without %s: %v", field, err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user struct {
+			ID      primitive.ObjectID       `bson:"_id"`
+			Name    string                   `bson:"name"`
+			Address entities.AddressResponse `bson:"address"`
+		}
+		if err := cursor.Decode(&user); err != nil {
+			return fmt.Errorf("failed to decode user: %v", err)
+		}
+
+		_, err := users.UpdateByID(ctx, user.ID, bson.M{
+			"$set":   bson.M{field: value(user.Name, user.Address)},
+			"$unset": bson.M{old: ""},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set %s on user %s: %v", field, user.ID.Hex(), err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate users: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/infrastructure/encryption"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// documentNumberField campo cifrado; entra como dado autenticado, igual ao repositório
+const documentNumberField = "document.document_number"
+
+// plaintextDocuments seleciona os registros gravados antes da criptografia ser ativada:
+// número em claro, sem índice cego. Registros anonimizados ficam de fora.
+var plaintextDocuments = bson.M{"$and": bson.A{
+	bson.M{documentNumberField: bson.M{"$exists": true}},
+	bson.M{documentNumberField: bson.M{"$not": primitive.Regex{Pattern: `^(enc|erased):`}}},
+}}
+
+// CountPlaintextDocuments conta os registros de users com o número do documento em claro
+func CountPlaintextDocuments(ctx context.Context, users *mongo.Collection) (int64, error) {
+	count, err := users.CountDocuments(ctx, plaintextDocuments)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count plaintext document numbers in %s: %v", users.Database().Name(), err)
+	}
+	return count, nil
+}
+
+// EncryptDocuments cifra os números de documento gravados em claro e preenche o
+// índice cego, com o lock de migrações. Diferente das migrações versionadas, depende
+// da configuração de criptografia, por isso é executada à parte (migrate
+// encrypt-documents) sempre que a criptografia for ativada. Percorre os mesmos bancos
+// das migrações. Idempotente.
+func (r *Runner) EncryptDocuments(ctx context.Context, cipher *encryption.FieldCipher) (int64, error) {
+	if cipher == nil {
+		return 0, fmt.Errorf("field encryption is not configured")
+	}
+
+	var total int64
+	err := r.withLock(ctx, func(ctx context.Context) error {
+		for _, t := range r.targets {
+			users := t.users
+			encrypted, err := encryptDocuments(ctx, cipher, users)
+			total += encrypted
+			if err != nil {
+				return err
+			}
+			if encrypted > 0 {
+				log.Printf("Encrypted %d document numbers in %s.%s", encrypted, users.Database().Name(), users.Name())
+			}
+		}
+		return nil
+	})
+	return total, err
+}
+
+func encryptDocuments(ctx context.Context, cipher *encryption.FieldCipher, users *mongo.Collection) (int64, error) {
+	cursor, err := users.Find(ctx, plaintextDocuments, options.Find().SetProjection(bson.M{documentNumberField: 1}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find plaintext document numbers: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var encrypted int64
+	for cursor.Next(ctx) {
+		var user struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			Document struct {
+				DocumentNumber string `bson:"document_number"`
+			} `bson:"document"`
+		}
+		if err := cursor.Decode(&user); err != nil {
+			return encrypted, fmt.Errorf("failed to decode user: %v", err)
+		}
+
+		normalized := entities.NormalizeDocumentNumber(user.Document.DocumentNumber)
+		sealed, err := cipher.Encrypt(documentNumberField, normalized)
+		if err != nil {
+			return encrypted, fmt.Errorf("failed to encrypt user %s: %v", user.ID.Hex(), err)
+		}
+
+		// O número atual no filtro evita sobrescrever uma alteração feita durante a execução
+		result, err := users.UpdateOne(ctx,
+			bson.M{"_id": user.ID, documentNumberField: user.Document.DocumentNumber},
+			bson.M{"$set": bson.M{
+				documentNumberField:      sealed,
+				"document.document_hash": cipher.BlindIndex(normalized),
+			}})
+		if mongo.IsDuplicateKeyError(err) {
+			// O valor não vai para a mensagem: é dado pessoal
+			return encrypted, fmt.Errorf("user %s duplicates another record of the same document; merge or erase one of them and rerun", user.ID.Hex())
+		}
+		if err != nil {
+			return encrypted, fmt.Errorf("failed to encrypt user %s: %v", user.ID.Hex(), err)
+		}
+		encrypted += result.ModifiedCount
+	}
+	if err := cursor.Err(); err != nil {
+		return encrypted, fmt.Errorf("failed to iterate users: %v", err)
+	}
+	return encrypted, nil
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migration altera o formato dos documentos da collection de usuários processados.
+// Up e Down devem ser idempotentes: uma execução interrompida é repetida por inteiro.
+// São executadas em cada banco (compartilhado e dedicados); o contexto traz o tenant
+// dono dos registros sem tenant_id daquele banco.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, users *mongo.Collection) error
+	// Down desfaz Up; nulo quando a migração é irreversível
+	Down func(ctx context.Context, users *mongo.Collection) error
+}
+
+// All lista as migrações em ordem de versão. Ao acrescentar uma, atualize
+// mongodb.SchemaVersion.
+func All() []Migration {
+	return []Migration{
+		backfillVersioning(),
+		normalizeDocumentNumbers(),
+		backfillSearchText(),
+		backfillTenant(),
+		mergeDuplicateDocuments(),
+		searchTokens(),
+	}
+}
+
+// validate garante versões contíguas a partir de 1
+func validate(migrations []Migration) error {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return fmt.Errorf("migration %q has version %d, expected %d", m.Description, m.Version, i+1)
+		}
+		if m.Up == nil {
+			return fmt.Errorf("migration %d has no Up step", m.Version)
+		}
+	}
+	return nil
+}
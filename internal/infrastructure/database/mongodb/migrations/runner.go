@@ -0,0 +1,400 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/infrastructure/config"
+	"api-rabbitmq/internal/infrastructure/database/mongodb"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	migrationsCollection = "schema_migrations"
+	lockID               = "lock"
+	// lockTTL validade do lock; renovado enquanto a execução continua, de modo que só
+	// expira se o processo morrer
+	lockTTL = 5 * time.Minute
+)
+
+var (
+	// ErrLocked outra instância está executando migrações
+	ErrLocked = errors.New("migrations are locked by another process")
+	// ErrLockLost o lock deixou de pertencer a esta execução, que foi interrompida
+	ErrLockLost = errors.New("migration lock lost")
+)
+
+// Status situação de uma migração em um banco
+type Status struct {
+	Database    string     `json:"database"`
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+	Reversible  bool       `json:"reversible"`
+}
+
+type appliedRecord struct {
+	Version     int           `bson:"version"`
+	Description string        `bson:"description"`
+	AppliedAt   time.Time     `bson:"applied_at"`
+	Duration    time.Duration `bson:"duration"`
+}
+
+// Runner aplica e reverte as migrações registrando-as em schema_migrations, no banco
+// compartilhado e em cada banco dedicado de tenant. O lock fica no banco
+// compartilhado e vale para todos.
+type Runner struct {
+	lock    *mongo.Collection
+	targets []target
+	list    []Migration
+	owner   string
+}
+
+// target collection de usuários migrada e o registro de migrações do seu banco
+type target struct {
+	// tenant dono dos registros sem tenant_id: o padrão no banco compartilhado
+	tenant     string
+	migrations *mongo.Collection
+	users      *mongo.Collection
+}
+
+func (t target) database() string {
+	return t.users.Database().Name()
+}
+
+// NewRunner prepara as migrações do banco compartilhado e dos bancos dedicados de
+// tenancy (que pode ser nulo: um único tenant)
+func NewRunner(client *mongo.Client, cfg *config.DatabaseConfig, tenancy *config.TenancyConfig) (*Runner, error) {
+	list := All()
+	if err := validate(list); err != nil {
+		return nil, err
+	}
+	if latest := list[len(list)-1].Version; latest != mongodb.SchemaVersion {
+		return nil, fmt.Errorf("latest migration is %d but mongodb.SchemaVersion is %d", latest, mongodb.SchemaVersion)
+	}
+
+	newTarget := func(tenant, name string) target {
+		database := client.Database(name)
+		return target{
+			tenant:     tenant,
+			migrations: database.Collection(migrationsCollection),
+			users:      database.Collection(cfg.CollectionName),
+		}
+	}
+	targets := []target{newTarget(entities.DefaultTenant, cfg.DatabaseName)}
+	if tenancy != nil {
+		for _, tenant := range tenancy.All() {
+			if tenancy.HasDedicatedDatabase(tenant) {
+				targets = append(targets, newTarget(tenant, tenancy.DatabaseName(cfg.DatabaseName, tenant)))
+			}
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	return &Runner{
+		lock:    targets[0].migrations,
+		targets: targets,
+		list:    list,
+		owner:   fmt.Sprintf("%s:%d:%d", hostname, os.Getpid(), time.Now().UnixNano()),
+	}, nil
+}
+
+// Latest versão mais recente conhecida
+func (r *Runner) Latest() int {
+	return r.list[len(r.list)-1].Version
+}
+
+// Status lista, banco a banco, todas as migrações com a indicação de aplicadas
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	statuses := make([]Status, 0, len(r.targets)*len(r.list))
+	for _, t := range r.targets {
+		applied, err := t.applied(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range r.list {
+			s := Status{Database: t.database(), Version: m.Version, Description: m.Description, Reversible: m.Down != nil}
+			if rec, ok := applied[m.Version]; ok {
+				s.Applied = true
+				s.AppliedAt = &rec.AppliedAt
+			}
+			statuses = append(statuses, s)
+		}
+	}
+	return statuses, nil
+}
+
+// Current maior versão aplicada em todos os bancos (0 quando algum não tem nenhuma)
+func (r *Runner) Current(ctx context.Context) (int, error) {
+	current := r.Latest()
+	for _, t := range r.targets {
+		applied, err := t.applied(ctx)
+		if err != nil {
+			return 0, err
+		}
+		version := 0
+		for v := range applied {
+			version = max(version, v)
+		}
+		current = min(current, version)
+	}
+	return current, nil
+}
+
+// Up aplica, em ordem, as migrações pendentes até target (0 = todas)
+func (r *Runner) Up(ctx context.Context, target int) error {
+	if target == 0 {
+		target = r.Latest()
+	}
+	if target < 0 || target > r.Latest() {
+		return fmt.Errorf("unknown migration version %d", target)
+	}
+
+	return r.withLock(ctx, func(ctx context.Context) error {
+		for _, t := range r.targets {
+			if err := r.up(ctx, t, target); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Runner) up(ctx context.Context, t target, version int) error {
+	applied, err := t.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx = entities.WithTenant(ctx, t.tenant)
+	for _, m := range r.list {
+		if m.Version > version {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		log.Printf("Applying migration %d to %s: %s", m.Version, t.database(), m.Description)
+		started := time.Now()
+		if err := m.Up(ctx, t.users); err != nil {
+			return fmt.Errorf("migration %d failed on %s: %w", m.Version, t.database(), err)
+		}
+		if err := t.setSchemaVersion(ctx, m.Version-1, m.Version); err != nil {
+			return err
+		}
+
+		_, err := t.migrations.InsertOne(ctx, appliedRecord{
+			Version:     m.Version,
+			Description: m.Description,
+			AppliedAt:   time.Now(),
+			Duration:    time.Since(started),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d on %s: %v", m.Version, t.database(), err)
+		}
+	}
+	return nil
+}
+
+// Down reverte, da mais recente para a mais antiga, as migrações acima de target
+func (r *Runner) Down(ctx context.Context, target int) error {
+	if target < 0 || target > r.Latest() {
+		return fmt.Errorf("unknown migration version %d", target)
+	}
+
+	return r.withLock(ctx, func(ctx context.Context) error {
+		// Irreversibilidade verificada antes de tocar em qualquer banco
+		for _, t := range r.targets {
+			applied, err := t.applied(ctx)
+			if err != nil {
+				return err
+			}
+			for _, m := range r.list {
+				if _, ok := applied[m.Version]; ok && m.Version > target && m.Down == nil {
+					return fmt.Errorf("migration %d (%s) is irreversible", m.Version, m.Description)
+				}
+			}
+		}
+
+		for _, t := range r.targets {
+			if err := r.down(ctx, t, target); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Runner) down(ctx context.Context, t target, version int) error {
+	applied, err := t.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx = entities.WithTenant(ctx, t.tenant)
+	for i := len(r.list) - 1; i >= 0; i-- {
+		m := r.list[i]
+		if m.Version <= version {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		log.Printf("Reverting migration %d on %s: %s", m.Version, t.database(), m.Description)
+		if err := m.Down(ctx, t.users); err != nil {
+			return fmt.Errorf("reverting migration %d failed on %s: %w", m.Version, t.database(), err)
+		}
+		if err := t.setSchemaVersion(ctx, m.Version, m.Version-1); err != nil {
+			return err
+		}
+		if _, err := t.migrations.DeleteOne(ctx, bson.M{"version": m.Version}); err != nil {
+			return fmt.Errorf("failed to unrecord migration %d on %s: %v", m.Version, t.database(), err)
+		}
+	}
+	return nil
+}
+
+func (t target) applied(ctx context.Context) (map[int]appliedRecord, error) {
+	cursor, err := t.migrations.Find(ctx, bson.M{"version": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations of %s: %v", t.database(), err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []appliedRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode applied migrations: %v", err)
+	}
+
+	applied := make(map[int]appliedRecord, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// setSchemaVersion move os registros da versão from (ou sem versão, quando from é 0) para to.
+// Registros já gravados em versão mais nova pelo repositório não são tocados.
+func (t target) setSchemaVersion(ctx context.Context, from, to int) error {
+	filter := bson.M{"schema_version": from}
+	if from == 0 {
+		filter = bson.M{"$or": bson.A{
+			bson.M{"schema_version": bson.M{"$exists": false}},
+			bson.M{"schema_version": 0},
+		}}
+	}
+
+	update := bson.M{"$set": bson.M{"schema_version": to}}
+	if to == 0 {
+		update = bson.M{"$unset": bson.M{"schema_version": ""}}
+	}
+
+	if _, err := t.users.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to set schema_version %d on %s: %v", to, t.database(), err)
+	}
+	return nil
+}
+
+// withLock executa fn com o lock de migrações, renovando-o até fn terminar. Um lock
+// vencido (processo que morreu) é assumido. Se a renovação falhar ou o lock já
+// tiver sido assumido por outro processo, o contexto de fn é cancelado, para que
+// duas instâncias nunca migrem ao mesmo tempo.
+func (r *Runner) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := r.acquire(ctx); err != nil {
+		return err
+	}
+	defer r.release()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	go func() {
+		ticker := time.NewTicker(lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.renew(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("Aborting migrations: %v", err)
+					cancel(err)
+					return
+				}
+			}
+		}
+	}()
+
+	err := fn(ctx)
+	if cause := context.Cause(ctx); cause != nil && !errors.Is(cause, context.Canceled) {
+		// A causa (lock perdido) explica melhor que o erro de cancelamento de fn
+		return cause
+	}
+	return err
+}
+
+// renew estende a validade do lock; ErrLockLost se ele não pertence mais a este processo
+func (r *Runner) renew(ctx context.Context) error {
+	// A renovação precisa terminar antes do lock vencer
+	ctx, cancel := context.WithTimeout(ctx, lockTTL/3)
+	defer cancel()
+
+	result, err := r.lock.UpdateOne(ctx,
+		bson.M{"_id": lockID, "owner": r.owner},
+		bson.M{"$set": bson.M{"expires_at": time.Now().Add(lockTTL)}})
+	if err != nil {
+		return fmt.Errorf("%w: failed to renew: %v", ErrLockLost, err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: taken over by another process", ErrLockLost)
+	}
+	return nil
+}
+
+func (r *Runner) acquire(ctx context.Context) error {
+	now := time.Now()
+	lock := bson.M{"_id": lockID, "owner": r.owner, "acquired_at": now, "expires_at": now.Add(lockTTL)}
+
+	_, err := r.lock.InsertOne(ctx, lock)
+	if err == nil {
+		return nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to acquire migration lock: %v", err)
+	}
+
+	err = r.lock.FindOneAndReplace(ctx,
+		bson.M{"_id": lockID, "expires_at": bson.M{"$lt": now}}, lock).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		var holder struct {
+			Owner     string    `bson:"owner"`
+			ExpiresAt time.Time `bson:"expires_at"`
+		}
+		_ = r.lock.FindOne(ctx, bson.M{"_id": lockID}).Decode(&holder)
+		return fmt.Errorf("%w (held by %s until %s)", ErrLocked, holder.Owner, holder.ExpiresAt.Format(time.RFC3339))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %v", err)
+	}
+	log.Printf("Took over expired migration lock")
+	return nil
+}
+
+func (r *Runner) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := r.lock.DeleteOne(ctx, bson.M{"_id": lockID, "owner": r.owner}); err != nil {
+		log.Printf("Warning: failed to release migration lock: %v", err)
+	}
+}
@@ -0,0 +1,6 @@
+package mongodb
+
+// SchemaVersion versão do formato dos documentos gravados por este repositório.
+// Deve acompanhar a última migração de migrations.All; registros com versão menor
+// são atualizados por cmd/migrate.
+const SchemaVersion = 6
@@ -0,0 +1,98 @@
+package mongodb
+
+import (
+	"context"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+	"api-rabbitmq/internal/infrastructure/config"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const tenantField = "tenant_id"
+
+// tenantCollections resolve a collection de cada tenant: a do banco dedicado, quando
+// configurado, ou a compartilhada
+type tenantCollections struct {
+	shared    *mongo.Collection
+	dedicated map[string]*mongo.Collection
+	// multiTenant indica que mais de um tenant grava na collection compartilhada
+	multiTenant bool
+}
+
+// newTenantCollections prepara a collection name no banco compartilhado e nos bancos
+// dedicados de tenancy (que pode ser nulo: um único tenant)
+func newTenantCollections(client *mongo.Client, cfg *config.DatabaseConfig, tenancy *config.TenancyConfig, name string) tenantCollections {
+	c := tenantCollections{
+		shared:    client.Database(cfg.DatabaseName).Collection(name),
+		dedicated: make(map[string]*mongo.Collection),
+	}
+	if tenancy == nil || !tenancy.Enabled {
+		return c
+	}
+
+	c.multiTenant = true
+	for _, tenant := range tenancy.All() {
+		if database := tenancy.DatabaseName(cfg.DatabaseName, tenant); database != cfg.DatabaseName {
+			c.dedicated[tenant] = client.Database(database).Collection(name)
+		}
+	}
+	return c
+}
+
+// UserCollections lista a collection de usuários processados do banco compartilhado e
+// dos bancos dedicados, para rotinas de manutenção que percorrem todos os tenants
+func UserCollections(client *mongo.Client, cfg *config.DatabaseConfig, tenancy *config.TenancyConfig) []*mongo.Collection {
+	return newTenantCollections(client, cfg, tenancy, cfg.CollectionName).all()
+}
+
+// all lista a collection compartilhada seguida das dedicadas
+func (c tenantCollections) all() []*mongo.Collection {
+	collections := []*mongo.Collection{c.shared}
+	for _, collection := range c.dedicated {
+		collections = append(collections, collection)
+	}
+	return collections
+}
+
+// scope resolve o tenant de ctx; ErrTenantRequired quando não há um
+func (c tenantCollections) scope(ctx context.Context) (tenantScope, error) {
+	tenant, ok := entities.TenantFromContext(ctx)
+	if !ok {
+		return tenantScope{}, repositories.ErrTenantRequired
+	}
+	if collection, ok := c.dedicated[tenant]; ok {
+		return tenantScope{tenant: tenant, collection: collection, exclusive: true}, nil
+	}
+	return tenantScope{tenant: tenant, collection: c.shared, exclusive: !c.multiTenant}, nil
+}
+
+// tenantScope collection e tenant de uma operação. Todo filtro passa por match,
+// mesmo em banco dedicado.
+type tenantScope struct {
+	tenant     string
+	collection *mongo.Collection
+	// exclusive indica que nenhum outro tenant grava na collection
+	exclusive bool
+}
+
+// match acrescenta o tenant a filter, sem alterá-lo
+func (s tenantScope) match(filter bson.M) bson.M {
+	scoped := make(bson.M, len(filter)+1)
+	for k, v := range filter {
+		scoped[k] = v
+	}
+	scoped[tenantField] = tenantValue(s.tenant)
+	return scoped
+}
+
+// tenantValue condição sobre tenant_id; o tenant padrão também seleciona os registros
+// gravados antes da multi-tenancy, que não têm o campo
+func tenantValue(tenant string) interface{} {
+	if tenant == entities.DefaultTenant {
+		return bson.M{"$in": bson.A{tenant, nil}}
+	}
+	return tenant
+}
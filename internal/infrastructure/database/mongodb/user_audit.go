@@ -0,0 +1,88 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditFailures conta os eventos de auditoria que não foram gravados. A escrita no
+// usuário já está confirmada quando a auditoria falha, então o evento é perdido;
+// o contador aparece no /readyz para que a perda não fique apenas no log.
+type AuditFailures struct {
+	mu      sync.Mutex
+	count   int64
+	lastErr string
+	lastAt  time.Time
+}
+
+// AuditFailureStats detalhes da verificação de auditoria do /readyz
+type AuditFailureStats struct {
+	Failures      int64      `json:"failures"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+}
+
+func (f *AuditFailures) record(err error) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.count++
+	f.lastErr = err.Error()
+	f.lastAt = time.Now()
+}
+
+// Stats falhas acumuladas desde o início do processo
+func (f *AuditFailures) Stats() AuditFailureStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats := AuditFailureStats{Failures: f.count, LastError: f.lastErr}
+	if f.count > 0 {
+		at := f.lastAt
+		stats.LastFailureAt = &at
+	}
+	return stats
+}
+
+// Check verificação do /readyz com as falhas de auditoria
+func (f *AuditFailures) Check(ctx context.Context) (any, error) {
+	return f.Stats(), nil
+}
+
+// recordAudit grava a alteração na trilha de auditoria. A escrita no usuário já
+// foi confirmada, então uma falha aqui é registrada no log e em AuditFailures.
+func (r *UserRepositoryImpl) recordAudit(ctx context.Context, action entities.AuditAction, id primitive.ObjectID, before, after *entities.ProcessedUser) {
+	if r.audit == nil {
+		return
+	}
+
+	origin := entities.AuditFromContext(ctx)
+	tenant, _ := entities.TenantFromContext(ctx)
+	event := &entities.AuditEvent{
+		TenantID:      tenant,
+		UserID:        id.Hex(),
+		Action:        action,
+		Actor:         origin.Actor,
+		CorrelationID: origin.CorrelationID,
+		Changes:       entities.DiffProcessedUser(before, after),
+		OccurredAt:    time.Now(),
+	}
+	if after != nil {
+		event.Version = after.Version
+	}
+	for _, document := range entities.SubjectDocuments(before, after) {
+		event.SubjectKeys = append(event.SubjectKeys, subjectKey(r.cipher, document))
+	}
+
+	if err := r.audit.Append(ctx, event); err != nil {
+		r.auditFailures.record(err)
+		log.Printf("Warning: failed to write audit event for user %s: %v", id.Hex(), err)
+	}
+}
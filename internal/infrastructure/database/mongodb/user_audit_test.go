@@ -0,0 +1,37 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAuditFailuresCountsLostEvents(t *testing.T) {
+	failures := &AuditFailures{}
+
+	details, err := failures.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if stats := details.(AuditFailureStats); stats.Failures != 0 || stats.LastFailureAt != nil {
+		t.Fatalf("stats before failures = %+v, want empty", stats)
+	}
+
+	failures.record(errors.New("first"))
+	failures.record(errors.New("timeout"))
+
+	stats := failures.Stats()
+	if stats.Failures != 2 {
+		t.Errorf("Failures = %d, want 2", stats.Failures)
+	}
+	if stats.LastError != "timeout" {
+		t.Errorf("LastError = %q, want the latest error", stats.LastError)
+	}
+	if stats.LastFailureAt == nil {
+		t.Error("LastFailureAt is nil after a failure")
+	}
+
+	// Sem contador configurado a falha só vai para o log
+	var disabled *AuditFailures
+	disabled.record(errors.New("ignored"))
+}
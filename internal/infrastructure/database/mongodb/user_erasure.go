@@ -0,0 +1,56 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (r *UserRepositoryImpl) Name() string {
+	return r.collections.shared.Name()
+}
+
+// EraseSubject remove ou anonimiza todos os registros do documento. Na anonimização
+// são mantidos apenas status, validade do documento, cidade, estado e datas; o
+// número do documento é trocado por um marcador único para não violar o índice único.
+func (r *UserRepositoryImpl) EraseSubject(ctx context.Context, documentNumber string, mode repositories.ErasureMode) (int64, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return 0, err
+	}
+	filter := s.match(r.documentFilter(documentNumber))
+
+	if mode == repositories.ErasureDelete {
+		result, err := s.collection.DeleteMany(ctx, filter)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete subject records: %v", err)
+		}
+		return result.DeletedCount, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"name":                     entities.ErasedPlaceholder,
+			"document.document_number": bson.M{"$concat": bson.A{"erased:", bson.M{"$toString": "$_id"}}},
+			"address.street":           "",
+			"address.zipcode":          "",
+			"status":                   entities.ErasedStatus,
+			"message":                  entities.ErasedMessage,
+			"updated_at":               time.Now(),
+			"version":                  bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+		}}},
+		{{Key: "$unset", Value: bson.A{"history", "document.document_hash", "search_tokens"}}},
+	}
+
+	result, err := s.collection.UpdateMany(ctx, filter, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("failed to anonymize subject records: %v", err)
+	}
+	return result.ModifiedCount, nil
+}
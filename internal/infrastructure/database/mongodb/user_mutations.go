@@ -0,0 +1,212 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// updateRetries tentativas de um update sem checagem de versão sob escrita concorrente
+const updateRetries = 3
+
+func (r *UserRepositoryImpl) Update(ctx context.Context, id string, update entities.ProcessedUserUpdate, expectedVersion int64) (*entities.ProcessedUser, error) {
+	document, err := r.sealDocument(update.Document)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.applyUpdate(ctx, id, bson.M{
+		"name":     update.Name,
+		"document": document,
+		"address":  update.Address,
+		"status":   update.Status,
+		"message":  update.Message,
+		// Substituição completa: o registro passa a estar no formato atual
+		"schema_version": SchemaVersion,
+	}, expectedVersion)
+}
+
+func (r *UserRepositoryImpl) Patch(ctx context.Context, id string, patch entities.ProcessedUserPatch, expectedVersion int64) (*entities.ProcessedUser, error) {
+	set := bson.M{}
+	if patch.Name != nil {
+		set["name"] = *patch.Name
+	}
+	if patch.Document != nil && patch.Document.DocumentNumber != nil {
+		sealed, err := r.sealDocument(entities.DocumentUserProcessed{DocumentNumber: *patch.Document.DocumentNumber})
+		if err != nil {
+			return nil, err
+		}
+		set["document.document_number"] = sealed.DocumentNumber
+		if sealed.DocumentHash != "" {
+			set["document.document_hash"] = sealed.DocumentHash
+		}
+	}
+	if patch.Document != nil && patch.Document.IsValid != nil {
+		set["document.is_valid"] = *patch.Document.IsValid
+	}
+	if patch.Address != nil {
+		set["address"] = *patch.Address
+	}
+	if patch.Status != nil {
+		set["status"] = *patch.Status
+	}
+	if patch.Message != nil {
+		set["message"] = *patch.Message
+	}
+
+	return r.applyUpdate(ctx, id, set, expectedVersion)
+}
+
+func (r *UserRepositoryImpl) Delete(ctx context.Context, id string, expectedVersion int64) error {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", repositories.ErrInvalidID, err)
+	}
+
+	var deleted entities.ProcessedUser
+	err = s.collection.FindOneAndDelete(ctx, s.match(versionFilter(objectID, expectedVersion))).Decode(&deleted)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return missingOrConflict(ctx, s, objectID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+	if err := r.openUser(&deleted); err != nil {
+		return err
+	}
+	r.recordAudit(ctx, entities.AuditDelete, objectID, &deleted, nil)
+
+	return nil
+}
+
+// DeleteVersions remove registro a registro: a auditoria precisa saber quais foram de
+// fato removidos, o que DeleteMany não informa
+func (r *UserRepositoryImpl) DeleteVersions(ctx context.Context, refs []repositories.UserVersion) (int64, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	objectIDs := make([]primitive.ObjectID, 0, len(refs))
+	for _, ref := range refs {
+		objectID, err := primitive.ObjectIDFromHex(ref.ID)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", repositories.ErrInvalidID, err)
+		}
+		objectIDs = append(objectIDs, objectID)
+	}
+
+	var deleted int64
+	for i, objectID := range objectIDs {
+		result, err := s.collection.DeleteOne(ctx, s.match(versionFilter(objectID, refs[i].Version)))
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete users: %v", err)
+		}
+		if result.DeletedCount == 0 {
+			continue
+		}
+		deleted++
+		// Remoção em lote (ex: retenção): os valores já foram arquivados, o evento registra apenas o fato
+		r.recordAudit(ctx, entities.AuditDelete, objectID, nil, nil)
+	}
+	return deleted, nil
+}
+
+// applyUpdate aplica $set no registro se a versão coincidir, incrementando a versão.
+// O registro é lido antes para que a auditoria tenha o estado anterior exato; com
+// AnyVersion, uma escrita concorrente entre a leitura e o update leva a nova tentativa.
+func (r *UserRepositoryImpl) applyUpdate(ctx context.Context, id string, set bson.M, expectedVersion int64) (*entities.ProcessedUser, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repositories.ErrInvalidID, err)
+	}
+
+	set["updated_at"] = time.Now()
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	for attempt := 0; attempt < updateRetries; attempt++ {
+		var before entities.ProcessedUser
+		err := s.collection.FindOne(ctx, s.match(bson.M{"_id": objectID})).Decode(&before)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", repositories.ErrNotFound, id)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user: %v", err)
+		}
+		if expectedVersion != repositories.AnyVersion && before.Version != expectedVersion {
+			return nil, repositories.ErrVersionConflict
+		}
+		refreshSearchTokens(&before, set)
+
+		var user entities.ProcessedUser
+		err = s.collection.FindOneAndUpdate(ctx, s.match(versionFilter(objectID, before.Version)), update, opts).Decode(&user)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments) && expectedVersion == repositories.AnyVersion:
+			continue
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, missingOrConflict(ctx, s, objectID)
+		case mongo.IsDuplicateKeyError(err):
+			return nil, repositories.ErrDuplicate
+		case err != nil:
+			return nil, fmt.Errorf("failed to update user: %v", err)
+		}
+		if err := r.openUser(&before); err != nil {
+			return nil, err
+		}
+		if err := r.openUser(&user); err != nil {
+			return nil, err
+		}
+		r.recordAudit(ctx, entities.AuditUpdate, objectID, &before, &user)
+
+		return &user, nil
+	}
+	return nil, repositories.ErrVersionConflict
+}
+
+// versionFilter seleciona o registro apenas na versão esperada. Registros gravados
+// antes do controle de versão não têm o campo e equivalem à versão 0.
+func versionFilter(id primitive.ObjectID, expectedVersion int64) bson.M {
+	switch expectedVersion {
+	case repositories.AnyVersion:
+		return bson.M{"_id": id}
+	case 0:
+		return bson.M{"_id": id, "$or": bson.A{
+			bson.M{"version": 0},
+			bson.M{"version": bson.M{"$exists": false}},
+		}}
+	}
+	return bson.M{"_id": id, "version": expectedVersion}
+}
+
+// missingOrConflict distingue, após uma escrita sem efeito, registro inexistente de versão divergente
+func missingOrConflict(ctx context.Context, s tenantScope, id primitive.ObjectID) error {
+	count, err := s.collection.CountDocuments(ctx, s.match(bson.M{"_id": id}), options.Count().SetLimit(1))
+	if err != nil {
+		return fmt.Errorf("failed to check user: %v", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s", repositories.ErrNotFound, id.Hex())
+	}
+	return repositories.ErrVersionConflict
+}
@@ -0,0 +1,179 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *UserRepositoryImpl) Find(ctx context.Context, query repositories.UserQuery) (*repositories.UserPage, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+
+	filter, err := buildFilter(s, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Busca um item a mais para saber se existe próxima página
+	findOptions := options.Find().
+		SetSort(sortSpec(query)).
+		SetLimit(int64(query.Limit + 1))
+
+	cursor, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find users: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	users := make([]entities.ProcessedUser, 0, query.Limit+1)
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %v", err)
+	}
+
+	if err := r.openUsers(users); err != nil {
+		return nil, err
+	}
+
+	page := &repositories.UserPage{Items: users}
+	if len(users) > query.Limit {
+		page.Items = users[:query.Limit]
+		page.NextCursor = repositories.NewPageCursor(query, page.Items[query.Limit-1]).Encode()
+	}
+	return page, nil
+}
+
+func (r *UserRepositoryImpl) ForEach(ctx context.Context, query repositories.UserQuery, fn func(user *entities.ProcessedUser) error) error {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return err
+	}
+	query.Cursor = ""
+	if err := query.Normalize(); err != nil {
+		return err
+	}
+
+	filter, err := buildFilter(s, query)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetSort(sortSpec(query)))
+	if err != nil {
+		return fmt.Errorf("failed to find users: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user entities.ProcessedUser
+		if err := cursor.Decode(&user); err != nil {
+			return fmt.Errorf("failed to decode user: %v", err)
+		}
+		if err := r.openUser(&user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate users: %v", err)
+	}
+	return nil
+}
+
+// buildFilter traduz os filtros e o cursor da consulta para um filtro do MongoDB,
+// restrito ao tenant de s
+func buildFilter(s tenantScope, query repositories.UserQuery) (bson.D, error) {
+	filter := bson.D{{Key: tenantField, Value: tenantValue(s.tenant)}}
+
+	if query.Status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: query.Status})
+	}
+	if query.DocumentValid != nil {
+		filter = append(filter, bson.E{Key: "document.is_valid", Value: *query.DocumentValid})
+	}
+	if query.State != "" {
+		filter = append(filter, bson.E{Key: "address.state", Value: query.State})
+	}
+	if query.City != "" {
+		filter = append(filter, bson.E{Key: "address.city", Value: query.City})
+	}
+
+	createdAt := bson.D{}
+	if !query.CreatedFrom.IsZero() {
+		createdAt = append(createdAt, bson.E{Key: "$gte", Value: query.CreatedFrom})
+	}
+	if !query.CreatedTo.IsZero() {
+		createdAt = append(createdAt, bson.E{Key: "$lt", Value: query.CreatedTo})
+	}
+	if len(createdAt) > 0 {
+		filter = append(filter, bson.E{Key: "created_at", Value: createdAt})
+	}
+
+	if query.Cursor == "" {
+		return filter, nil
+	}
+
+	keyset, err := keysetFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	return append(filter, bson.E{Key: "$or", Value: keyset}), nil
+}
+
+// keysetFilter retorna a condição "depois do cursor": (campo, _id) estritamente
+// maior (asc) ou menor (desc) que a última linha entregue
+func keysetFilter(query repositories.UserQuery) (bson.A, error) {
+	c, err := repositories.DecodeCursor(query.Cursor, query)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repositories.ErrInvalidCursor, err)
+	}
+
+	var value interface{} = c.Value
+	if c.SortBy == repositories.SortByCreatedAt {
+		createdAt, err := c.CreatedAt()
+		if err != nil {
+			return nil, err
+		}
+		value = createdAt
+	}
+
+	op := "$gt"
+	if c.SortOrder == repositories.SortDescending {
+		op = "$lt"
+	}
+	field := string(c.SortBy)
+
+	return bson.A{
+		bson.D{{Key: field, Value: bson.D{{Key: op, Value: value}}}},
+		bson.D{{Key: field, Value: value}, {Key: "_id", Value: bson.D{{Key: op, Value: id}}}},
+	}, nil
+}
+
+func sortSpec(query repositories.UserQuery) bson.D {
+	direction := 1
+	if query.SortOrder == repositories.SortDescending {
+		direction = -1
+	}
+	return bson.D{
+		{Key: string(query.SortBy), Value: direction},
+		{Key: "_id", Value: direction},
+	}
+}
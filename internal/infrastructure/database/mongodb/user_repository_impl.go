@@ -2,12 +2,15 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"api-rabbitmq/internal/domain/entities"
 	"api-rabbitmq/internal/domain/repositories"
+	"api-rabbitmq/internal/infrastructure/config"
+	"api-rabbitmq/internal/infrastructure/encryption"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,55 +19,221 @@ import (
 )
 
 type UserRepositoryImpl struct {
-	client     *mongo.Client
-	collection *mongo.Collection
-	connected  bool
+	client       *mongo.Client
+	collections  tenantCollections
+	connected    bool
+	dedupePolicy repositories.DedupePolicy
+	cipher       *encryption.FieldCipher
+	retentionTTL time.Duration
+	audit        repositories.AuditRepository
+	// auditFailures, quando não nulo, conta os eventos de auditoria perdidos
+	auditFailures *AuditFailures
+	// preImages indica que a collection compartilhada guarda pré-imagens (ver Watch)
+	preImages bool
 }
 
-func NewUserRepository(connectionString string) (repositories.UserRepository, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// UserRepositoryOptions recursos opcionais do repositório
+type UserRepositoryOptions struct {
+	// Cipher, quando não nulo, cifra o número do documento em repouso e as buscas
+	// por documento passam a usar o índice cego
+	Cipher *encryption.FieldCipher
+	// RetentionTTL, quando positivo, transforma o índice de created_at em índice TTL
+	RetentionTTL time.Duration
+	// Audit, quando não nulo, recebe um evento para cada alteração de registro
+	Audit repositories.AuditRepository
+	// AuditFailures, quando não nulo, conta os eventos que Audit não gravou
+	AuditFailures *AuditFailures
+	// Tenancy, quando não nulo, direciona os tenants com banco dedicado para ele
+	Tenancy *config.TenancyConfig
+}
+
+// NewUserRepository cria o repositório sobre um cliente já conectado (ver Connect).
+// Close desconecta o cliente, portanto o repositório passa a ser seu dono.
+func NewUserRepository(client *mongo.Client, cfg *config.DatabaseConfig, opts UserRepositoryOptions) (repositories.UserRepository, error) {
+	dedupePolicy, err := repositories.ParseDedupePolicy(cfg.DedupePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectionTimeout(cfg))
 	defer cancel()
 
-	clientOptions := options.Client().ApplyURI(connectionString)
-	client, err := mongo.Connect(ctx, clientOptions)
+	repo := &UserRepositoryImpl{
+		client:        client,
+		collections:   newTenantCollections(client, cfg, opts.Tenancy, cfg.CollectionName),
+		connected:     true,
+		dedupePolicy:  dedupePolicy,
+		cipher:        opts.Cipher,
+		retentionTTL:  opts.RetentionTTL,
+		audit:         opts.Audit,
+		auditFailures: opts.AuditFailures,
+	}
+
+	if repo.collections.multiTenant {
+		if err := enablePreImages(ctx, repo.collections.shared); err != nil {
+			log.Printf("Warning: change stream pre-images unavailable on %s, watching shared tenants is disabled: %v",
+				cfg.CollectionName, err)
+		} else {
+			repo.preImages = true
+		}
+	}
+
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (r *UserRepositoryImpl) Save(ctx context.Context, user *entities.ProcessedUser) (*repositories.SaveResult, error) {
+	switch r.dedupePolicy {
+	case repositories.DedupeInsert:
+		return r.insert(ctx, user)
+	case repositories.DedupeReject:
+		return r.insertUnique(ctx, user)
+	default:
+		return r.upsert(ctx, user)
+	}
+}
+
+func (r *UserRepositoryImpl) insert(ctx context.Context, user *entities.ProcessedUser) (*repositories.SaveResult, error) {
+	s, err := r.collections.scope(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to MongoDB: %v", err)
+		return nil, err
 	}
 
-	err = client.Ping(ctx, nil)
+	now := time.Now()
+	user.TenantID = s.tenant
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	user.Version = 1
+	user.History = []entities.ProcessingRecord{entities.NewProcessingRecord(user, now)}
+
+	stored := *user
+	stored.SchemaVersion = SchemaVersion
+	stored.SearchTokens = entities.SearchTokensFor(user.Name, user.Address)
+	sealed, err := r.sealDocument(user.Document)
 	if err != nil {
-		return nil, fmt.Errorf("failed to ping MongoDB: %v", err)
+		return nil, err
 	}
+	stored.Document = sealed
 
-	database := client.Database("userdb")
-	collection := database.Collection("processed_users")
+	result, err := s.collection.InsertOne(ctx, stored)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, repositories.ErrDuplicate
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert user: %v", err)
+	}
 
-	log.Println("Successfully connected to MongoDB")
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		user.ID = oid
+	}
+	r.recordAudit(ctx, entities.AuditCreate, user.ID, nil, user)
 
-	return &UserRepositoryImpl{
-		client:     client,
-		collection: collection,
-		connected:  true,
-	}, nil
+	return &repositories.SaveResult{ID: user.ID.Hex(), Created: true}, nil
 }
 
-func (r *UserRepositoryImpl) Save(ctx context.Context, user *entities.ProcessedUser) (string, error) {
-	user.CreatedAt = time.Now()
+// insertUnique verifica o documento antes de inserir; o índice único cobre inserções concorrentes
+func (r *UserRepositoryImpl) insertUnique(ctx context.Context, user *entities.ProcessedUser) (*repositories.SaveResult, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.collection.FindOne(ctx, s.match(r.documentFilter(user.Document.DocumentNumber)),
+		options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if err == nil {
+		return nil, repositories.ErrDuplicate
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to check duplicate user: %v", err)
+	}
 
-	result, err := r.collection.InsertOne(ctx, user)
+	return r.insert(ctx, user)
+}
+
+// upsert atualiza o registro do documento (ou cria um novo) e acrescenta o processamento ao histórico
+func (r *UserRepositoryImpl) upsert(ctx context.Context, user *entities.ProcessedUser) (*repositories.SaveResult, error) {
+	s, err := r.collections.scope(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to insert user: %v", err)
+		return nil, err
 	}
 
-	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
-		return oid.Hex(), nil
+	sealed, err := r.sealDocument(user.Document)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			tenantField:      s.tenant,
+			"name":           user.Name,
+			"document":       sealed,
+			"address":        user.Address,
+			"status":         user.Status,
+			"message":        user.Message,
+			"updated_at":     now,
+			"schema_version": SchemaVersion,
+			"search_tokens":  entities.SearchTokensFor(user.Name, user.Address),
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+		"$inc":         bson.M{"version": 1},
+		"$push": bson.M{"history": bson.M{
+			"$each":  []entities.ProcessingRecord{entities.NewProcessingRecord(user, now)},
+			"$slice": -repositories.MaxHistoryRecords,
+		}},
+	}
+	// O estado anterior alimenta a trilha de auditoria; nenhum documento significa inserção
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+	filter := s.match(r.documentFilter(user.Document.DocumentNumber))
+
+	before := &entities.ProcessedUser{}
+	err = s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(before)
+	if mongo.IsDuplicateKeyError(err) {
+		// Outro worker inseriu o mesmo documento entre a busca e a inserção; a nova tentativa vira update
+		err = s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(before)
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		// A mensagem do driver repete o valor da chave duplicada; não deve chegar aos logs
+		return nil, fmt.Errorf("failed to upsert user: concurrent insert conflict")
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		before, err = nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert user: %v", err)
 	}
 
-	return "", nil
+	var saved entities.ProcessedUser
+	if err := s.collection.FindOne(ctx, filter).Decode(&saved); err != nil {
+		return nil, fmt.Errorf("failed to read upserted user: %v", err)
+	}
+	if err := r.openUser(&saved); err != nil {
+		return nil, err
+	}
+
+	action := entities.AuditReprocess
+	if before == nil {
+		action = entities.AuditCreate
+	} else if err := r.openUser(before); err != nil {
+		return nil, err
+	}
+	r.recordAudit(ctx, action, saved.ID, before, &saved)
+
+	*user = saved
+	return &repositories.SaveResult{ID: saved.ID.Hex(), Created: before == nil}, nil
 }
 
 func (r *UserRepositoryImpl) FindAll(ctx context.Context) ([]entities.ProcessedUser, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.collection.Find(ctx, s.match(bson.M{}))
 	if err != nil {
 		return nil, fmt.Errorf("failed to find users: %v", err)
 	}
@@ -74,21 +243,34 @@ func (r *UserRepositoryImpl) FindAll(ctx context.Context) ([]entities.ProcessedU
 	if err = cursor.All(ctx, &users); err != nil {
 		return nil, fmt.Errorf("failed to decode users: %v", err)
 	}
+	if err := r.openUsers(users); err != nil {
+		return nil, err
+	}
 
 	return users, nil
 }
 
 func (r *UserRepositoryImpl) FindByID(ctx context.Context, id string) (*entities.ProcessedUser, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid object ID: %v", err)
+		return nil, fmt.Errorf("%w: %v", repositories.ErrInvalidID, err)
 	}
 
 	var user entities.ProcessedUser
-	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
+	err = s.collection.FindOne(ctx, s.match(bson.M{"_id": objectID})).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", repositories.ErrNotFound, id)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find user: %v", err)
 	}
+	if err := r.openUser(&user); err != nil {
+		return nil, err
+	}
 
 	return &user, nil
 }
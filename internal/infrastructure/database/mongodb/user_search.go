@@ -0,0 +1,155 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// searchMaxTime limite de cada consulta da busca no servidor
+	searchMaxTime = 2 * time.Second
+	// codeMaxTimeMSExpired erro do servidor ao exceder searchMaxTime
+	codeMaxTimeMSExpired = 50
+)
+
+// Search busca primeiro pelo índice de texto (palavras inteiras, por relevância) e,
+// se a primeira página vier vazia, por prefixos de palavras em search_tokens
+func (r *UserRepositoryImpl) Search(ctx context.Context, query repositories.SearchQuery) (*repositories.UserPage, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+
+	position := repositories.SearchCursor{Mode: repositories.SearchFullText, Text: query.Text}
+	if query.Cursor != "" {
+		var err error
+		if position, err = repositories.DecodeSearchCursor(query.Cursor, query); err != nil {
+			return nil, err
+		}
+	}
+
+	var users []entities.ProcessedUser
+	var err error
+	if position.Mode == repositories.SearchFullText {
+		users, err = r.searchText(ctx, query.Text, position.Offset, query.Limit+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(users) == 0 && position.Offset == 0 {
+			position.Mode = repositories.SearchPartial
+		}
+	}
+	if position.Mode == repositories.SearchPartial {
+		users, err = r.searchPartial(ctx, query.Text, position.Offset, query.Limit+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.openUsers(users); err != nil {
+		return nil, err
+	}
+
+	page := &repositories.UserPage{Items: users}
+	if len(users) > query.Limit {
+		page.Items = users[:query.Limit]
+		position.Offset += query.Limit
+		page.NextCursor = position.Encode()
+	}
+	return page, nil
+}
+
+func (r *UserRepositoryImpl) searchText(ctx context.Context, text string, skip, limit int) ([]entities.ProcessedUser, error) {
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)).
+		SetMaxTime(searchMaxTime)
+
+	// Registros anonimizados têm nome "[erased]" e não podem aparecer na busca
+	filter := bson.M{
+		"$text":  bson.M{"$search": text},
+		"status": bson.M{"$ne": entities.ErasedStatus},
+	}
+	return r.findUsers(ctx, filter, opts)
+}
+
+// searchPartial exige que cada palavra do termo seja início de alguma palavra de
+// search_tokens (ex: "jo sil" encontra "João Silva"). A expressão ancorada no início
+// vira um intervalo do índice idx_tenant_search_tokens.
+func (r *UserRepositoryImpl) searchPartial(ctx context.Context, text string, skip, limit int) ([]entities.ProcessedUser, error) {
+	words := strings.Fields(entities.FoldSearchText(text))
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	conditions := make(bson.A, 0, len(words))
+	for _, w := range words {
+		conditions = append(conditions, bson.M{"search_tokens": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(w)}})
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)).
+		SetMaxTime(searchMaxTime)
+
+	return r.findUsers(ctx, bson.M{"$and": conditions}, opts)
+}
+
+func (r *UserRepositoryImpl) findUsers(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]entities.ProcessedUser, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.collection.Find(ctx, s.match(filter), opts)
+	if err != nil {
+		return nil, searchError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var users []entities.ProcessedUser
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, searchError(err)
+	}
+	return users, nil
+}
+
+// searchError trata o limite de tempo como termo amplo demais, que o cliente corrige
+func searchError(err error) error {
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && serverErr.HasErrorCode(codeMaxTimeMSExpired) {
+		return fmt.Errorf("%w: search text is too broad, use longer words", repositories.ErrInvalidQuery)
+	}
+	return fmt.Errorf("failed to search users: %v", err)
+}
+
+// refreshSearchTokens recalcula search_tokens quando um update altera nome ou endereço
+func refreshSearchTokens(before *entities.ProcessedUser, set bson.M) {
+	name, hasName := set["name"].(string)
+	address, hasAddress := set["address"].(entities.AddressResponse)
+	if !hasName && !hasAddress {
+		return
+	}
+	if !hasName {
+		name = before.Name
+	}
+	if !hasAddress {
+		address = before.Address
+	}
+	set["search_tokens"] = entities.SearchTokensFor(name, address)
+}
@@ -0,0 +1,146 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// statsFacets resultado bruto do $facet
+type statsFacets struct {
+	Total []struct {
+		Count int64 `bson:"count"`
+	} `bson:"total"`
+	ByStatus  []repositories.CountBucket `bson:"by_status"`
+	Documents []struct {
+		Valid bool  `bson:"_id"`
+		Count int64 `bson:"count"`
+	} `bson:"documents"`
+	ByState []repositories.CountBucket `bson:"by_state"`
+	ByCity  []repositories.CityCount   `bson:"by_city"`
+	Volume  []repositories.TimeBucket  `bson:"volume"`
+}
+
+// Stats calcula todas as estatísticas em uma única agregação. A primeira etapa
+// seleciona os registros criados ou processados no período; cada faceta então
+// aplica o próprio critério de data.
+func (r *UserRepositoryImpl) Stats(ctx context.Context, query repositories.StatsQuery) (*repositories.UserStats, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+
+	createdInRange := bson.D{{Key: "$match", Value: bson.M{
+		"created_at": bson.M{"$gte": query.From, "$lt": query.To},
+	}}}
+	countBy := func(key interface{}) bson.D {
+		return bson.D{{Key: "$group", Value: bson.M{"_id": key, "count": bson.M{"$sum": 1}}}}
+	}
+	byCountDesc := bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: s.match(bson.M{
+			"created_at": bson.M{"$lt": query.To},
+			"$or": bson.A{
+				bson.M{"created_at": bson.M{"$gte": query.From}},
+				bson.M{"updated_at": bson.M{"$gte": query.From}},
+			},
+		})}},
+		{{Key: "$facet", Value: bson.M{
+			"total": bson.A{createdInRange, bson.D{{Key: "$count", Value: "count"}}},
+			"by_status": bson.A{
+				createdInRange, countBy("$status"), byCountDesc,
+			},
+			"documents": bson.A{
+				createdInRange, countBy("$document.is_valid"),
+			},
+			"by_state": bson.A{
+				createdInRange, countBy("$address.state"), byCountDesc,
+			},
+			"by_city": bson.A{
+				createdInRange,
+				countBy(bson.M{"state": "$address.state", "city": "$address.city"}),
+				byCountDesc,
+				bson.D{{Key: "$limit", Value: repositories.MaxStatsCities}},
+				bson.D{{Key: "$project", Value: bson.M{
+					"_id": 0, "state": "$_id.state", "city": "$_id.city", "count": 1,
+				}}},
+			},
+			// Registros anteriores ao histórico contam como um processamento na criação
+			"volume": bson.A{
+				bson.D{{Key: "$project", Value: bson.M{
+					"processings": bson.M{"$ifNull": bson.A{
+						"$history", bson.A{bson.M{"processed_at": "$created_at"}},
+					}},
+				}}},
+				bson.D{{Key: "$unwind", Value: "$processings"}},
+				bson.D{{Key: "$match", Value: bson.M{
+					"processings.processed_at": bson.M{"$gte": query.From, "$lt": query.To},
+				}}},
+				countBy(bson.M{"$dateTrunc": bson.M{
+					"date":     "$processings.processed_at",
+					"unit":     string(query.Interval),
+					"timezone": query.Timezone,
+				}}),
+				bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}},
+			},
+		}}},
+	}
+
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate user stats: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var facets []statsFacets
+	if err := cursor.All(ctx, &facets); err != nil {
+		return nil, fmt.Errorf("failed to decode user stats: %v", err)
+	}
+
+	stats := &repositories.UserStats{
+		From:     query.From,
+		To:       query.To,
+		Interval: query.Interval,
+		Timezone: query.Timezone,
+		ByStatus: []repositories.CountBucket{},
+		ByState:  []repositories.CountBucket{},
+		ByCity:   []repositories.CityCount{},
+		Volume:   []repositories.TimeBucket{},
+	}
+	if len(facets) == 0 {
+		return stats, nil
+	}
+
+	f := facets[0]
+	if len(f.Total) > 0 {
+		stats.Total = f.Total[0].Count
+	}
+	for _, d := range f.Documents {
+		if d.Valid {
+			stats.Documents.Valid = d.Count
+		} else {
+			stats.Documents.Invalid = d.Count
+		}
+	}
+	if f.ByStatus != nil {
+		stats.ByStatus = f.ByStatus
+	}
+	if f.ByState != nil {
+		stats.ByState = f.ByState
+	}
+	if f.ByCity != nil {
+		stats.ByCity = f.ByCity
+	}
+	if f.Volume != nil {
+		stats.Volume = f.Volume
+	}
+	return stats, nil
+}
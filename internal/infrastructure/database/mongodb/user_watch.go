@@ -0,0 +1,170 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Códigos de erro do servidor relevantes para change streams
+const (
+	codeChangeStreamHistoryLost = 286
+	codeInvalidResumeToken      = 260
+	codeReplicaSetRequired      = 40573
+	codeNamespaceNotFound       = 26
+	codeNamespaceExists         = 48
+)
+
+type changeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument *entities.ProcessedUser `bson:"fullDocument"`
+	ClusterTime  primitive.Timestamp     `bson:"clusterTime"`
+}
+
+// enablePreImages liga as pré-imagens de change stream (MongoDB 6.0+) na collection,
+// criando-a se ainda não existir. Na collection compartilhada, a pré-imagem é o que
+// identifica o tenant de uma remoção. Cada alteração passa a guardar também o estado
+// anterior, que o servidor expira conforme changeStreamOptions.preAndPostImages.
+func enablePreImages(ctx context.Context, collection *mongo.Collection) error {
+	enabled := bson.M{"enabled": true}
+	collMod := bson.D{{Key: "collMod", Value: collection.Name()}, {Key: "changeStreamPreAndPostImages", Value: enabled}}
+
+	err := collection.Database().RunCommand(ctx, collMod).Err()
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Code != codeNamespaceNotFound {
+		return err
+	}
+
+	err = collection.Database().CreateCollection(ctx, collection.Name(),
+		options.CreateCollection().SetChangeStreamPreAndPostImages(enabled))
+	if errors.As(err, &cmdErr) && cmdErr.Code == codeNamespaceExists {
+		// Criada em paralelo por outra instância
+		return collection.Database().RunCommand(ctx, collMod).Err()
+	}
+	return err
+}
+
+type userChangeStream struct {
+	repo   *UserRepositoryImpl
+	stream *mongo.ChangeStream
+}
+
+// Watch abre um change stream sobre a collection do tenant. Exige MongoDB em replica set.
+// Na collection compartilhada entre tenants o evento de remoção traz apenas o _id: o
+// tenant vem da pré-imagem do documento (ver enablePreImages), sem a qual Watch
+// retorna ErrWatchUnsupported em vez de perder as remoções.
+func (r *UserRepositoryImpl) Watch(ctx context.Context, query repositories.WatchQuery) (repositories.UserChangeStream, error) {
+	s, err := r.collections.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !s.exclusive && !r.preImages {
+		return nil, repositories.ErrWatchUnsupported
+	}
+
+	match := bson.M{"operationType": bson.M{"$in": bson.A{
+		string(repositories.ChangeInsert), string(repositories.ChangeUpdate),
+		string(repositories.ChangeReplace), string(repositories.ChangeDelete),
+	}}}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if !s.exclusive {
+		// Sem o documento atual (remoção, ou update cuja busca chegou depois de uma
+		// remoção), vale o tenant da pré-imagem
+		match["$or"] = bson.A{
+			bson.M{"fullDocument." + tenantField: tenantValue(s.tenant)},
+			bson.M{"fullDocument": nil, "fullDocumentBeforeChange." + tenantField: tenantValue(s.tenant)},
+		}
+		opts.SetFullDocumentBeforeChange(options.WhenAvailable)
+	}
+	if query.Status != "" {
+		match["fullDocument.status"] = query.Status
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: match}}}
+
+	if query.ResumeToken != "" {
+		token, err := decodeResumeToken(query.ResumeToken)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetResumeAfter(token)
+	}
+
+	stream, err := s.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return nil, watchError(err)
+	}
+	return &userChangeStream{repo: r, stream: stream}, nil
+}
+
+func (s *userChangeStream) Next(ctx context.Context) (*repositories.UserChange, error) {
+	if !s.stream.Next(ctx) {
+		if err := s.stream.Err(); err != nil {
+			return nil, watchError(err)
+		}
+		return nil, ctx.Err()
+	}
+
+	var event changeEvent
+	if err := s.stream.Decode(&event); err != nil {
+		return nil, fmt.Errorf("failed to decode change event: %v", err)
+	}
+
+	change := &repositories.UserChange{
+		Type:        repositories.ChangeType(event.OperationType),
+		UserID:      event.DocumentKey.ID.Hex(),
+		At:          time.Unix(int64(event.ClusterTime.T), 0),
+		ResumeToken: base64.RawURLEncoding.EncodeToString(s.stream.ResumeToken()),
+	}
+	// Em updates o documento pode já ter sido removido quando a busca acontece
+	if event.FullDocument != nil {
+		if err := s.repo.openUser(event.FullDocument); err != nil {
+			return nil, err
+		}
+		change.User = event.FullDocument
+	}
+	return change, nil
+}
+
+func (s *userChangeStream) Close(ctx context.Context) error {
+	return s.stream.Close(ctx)
+}
+
+func decodeResumeToken(token string) (bson.Raw, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repositories.ErrInvalidResumeToken, err)
+	}
+	if err := bson.Raw(raw).Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", repositories.ErrInvalidResumeToken, err)
+	}
+	return bson.Raw(raw), nil
+}
+
+// watchError traduz os erros do servidor para os erros do repositório
+func watchError(err error) error {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		switch cmdErr.Code {
+		case codeReplicaSetRequired:
+			return repositories.ErrWatchUnsupported
+		case codeChangeStreamHistoryLost:
+			return repositories.ErrResumeTokenExpired
+		case codeInvalidResumeToken:
+			return repositories.ErrInvalidResumeToken
+		}
+	}
+	return fmt.Errorf("change stream failed: %v", err)
+}
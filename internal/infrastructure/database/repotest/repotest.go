@@ -0,0 +1,797 @@
+// Package repotest reúne a suíte de conformidade dos repositórios de usuários: os
+// mesmos cenários executados contra cada implementação garantem que o driver
+// inmemory e o MongoDB continuem com a mesma semântica.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+	"api-rabbitmq/internal/infrastructure/config"
+)
+
+// Tenants usados nos cenários com multi-tenancy: acme divide a collection com o
+// padrão, globex tem banco dedicado
+const (
+	sharedTenant    = "acme"
+	dedicatedTenant = "globex"
+)
+
+// Options configuração de cada conjunto de repositórios criado pela suíte
+type Options struct {
+	DedupePolicy repositories.DedupePolicy
+	// Tenancy nil equivale à multi-tenancy desligada
+	Tenancy *config.TenancyConfig
+}
+
+// Repositories repositórios criados pela Factory sobre um armazenamento vazio
+type Repositories struct {
+	Users repositories.UserRepository
+	// Audit trilha de auditoria ligada a Users
+	Audit repositories.AuditRepository
+	// InsertLegacy grava user como os registros anteriores à multi-tenancy, sem
+	// tenant, e preenche user.ID
+	InsertLegacy func(t *testing.T, user *entities.ProcessedUser)
+}
+
+// Factory cria os repositórios de um cenário; a liberação fica a cargo de t.Cleanup
+type Factory func(t *testing.T, opts Options) Repositories
+
+// Run executa a suíte contra a implementação criada por newRepos
+func Run(t *testing.T, newRepos Factory) {
+	t.Run("SaveDedupe", func(t *testing.T) { testSaveDedupe(t, newRepos) })
+	t.Run("ConcurrentSave", func(t *testing.T) { testConcurrentSave(t, newRepos) })
+	t.Run("FindKeyset", func(t *testing.T) { testFindKeyset(t, newRepos) })
+	t.Run("FindFilters", func(t *testing.T) { testFindFilters(t, newRepos) })
+	t.Run("ForEach", func(t *testing.T) { testForEach(t, newRepos) })
+	t.Run("Stats", func(t *testing.T) { testStats(t, newRepos) })
+	t.Run("VersionConflict", func(t *testing.T) { testVersionConflict(t, newRepos) })
+	t.Run("DeleteVersions", func(t *testing.T) { testDeleteVersions(t, newRepos) })
+	t.Run("EraseSubject", func(t *testing.T) { testEraseSubject(t, newRepos) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newRepos) })
+	t.Run("Watch", func(t *testing.T) { testWatch(t, newRepos) })
+}
+
+// MultiTenancy configuração com o tenant padrão, um tenant na collection
+// compartilhada e outro com banco dedicado
+func MultiTenancy() *config.TenancyConfig {
+	return &config.TenancyConfig{
+		Enabled:            true,
+		Tenants:            []string{entities.DefaultTenant, sharedTenant, dedicatedTenant},
+		DedicatedDatabases: []string{dedicatedTenant},
+	}
+}
+
+func tenantContext(tenant string) context.Context {
+	return entities.WithTenant(context.Background(), tenant)
+}
+
+func newUser(name, document, city string) *entities.ProcessedUser {
+	return &entities.ProcessedUser{
+		Name:     name,
+		Document: entities.DocumentUserProcessed{DocumentNumber: document, IsValid: true},
+		Address:  entities.AddressResponse{Street: "Rua das Flores", City: city, State: "SP", Zipcode: "13086656"},
+		Status:   "processed",
+		Message:  "User processed successfully",
+	}
+}
+
+func save(t *testing.T, ctx context.Context, users repositories.UserRepository, user *entities.ProcessedUser) *repositories.SaveResult {
+	t.Helper()
+	result, err := users.Save(ctx, user)
+	if err != nil {
+		t.Fatalf("Save(%s): %v", user.Name, err)
+	}
+	return result
+}
+
+func findByID(t *testing.T, ctx context.Context, users repositories.UserRepository, id string) *entities.ProcessedUser {
+	t.Helper()
+	user, err := users.FindByID(ctx, id)
+	if err != nil {
+		t.Fatalf("FindByID(%s): %v", id, err)
+	}
+	return user
+}
+
+func testSaveDedupe(t *testing.T, newRepos Factory) {
+	const formatted = "529.982.247-25"
+
+	t.Run("upsert", func(t *testing.T) {
+		repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert, Tenancy: MultiTenancy()})
+		ctx := tenantContext(entities.DefaultTenant)
+
+		first := save(t, ctx, repos.Users, newUser("Maria da Silva", "52998224725", "Campinas"))
+		if !first.Created {
+			t.Fatal("first save did not create the record")
+		}
+		second := save(t, ctx, repos.Users, newUser("Maria Silva", formatted, "Campinas"))
+		if second.Created || second.ID != first.ID {
+			t.Fatalf("second save = %+v, want an update of %s", second, first.ID)
+		}
+
+		user := findByID(t, ctx, repos.Users, first.ID)
+		if user.Name != "Maria Silva" || user.Version != 2 || len(user.History) != 2 {
+			t.Fatalf("upserted user = name %q, version %d, %d history records; want the second save on top of the first",
+				user.Name, user.Version, len(user.History))
+		}
+		if user.Document.DocumentNumber != "52998224725" {
+			t.Fatalf("document number = %q, want it normalized", user.Document.DocumentNumber)
+		}
+
+		// O mesmo documento em outro tenant é outro titular
+		other := save(t, tenantContext(sharedTenant), repos.Users, newUser("Maria da Silva", formatted, "Campinas"))
+		if !other.Created || other.ID == first.ID {
+			t.Fatalf("save in another tenant = %+v, want a new record", other)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		repos := newRepos(t, Options{DedupePolicy: repositories.DedupeReject})
+		ctx := tenantContext(entities.DefaultTenant)
+
+		save(t, ctx, repos.Users, newUser("Maria da Silva", "52998224725", "Campinas"))
+		if _, err := repos.Users.Save(ctx, newUser("Maria Silva", formatted, "Campinas")); !errors.Is(err, repositories.ErrDuplicate) {
+			t.Fatalf("duplicate save error = %v, want ErrDuplicate", err)
+		}
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		repos := newRepos(t, Options{DedupePolicy: repositories.DedupeInsert})
+		ctx := tenantContext(entities.DefaultTenant)
+
+		first := save(t, ctx, repos.Users, newUser("Maria da Silva", "52998224725", "Campinas"))
+		second := save(t, ctx, repos.Users, newUser("Maria Silva", formatted, "Campinas"))
+		if !second.Created || second.ID == first.ID {
+			t.Fatalf("second save = %+v, want a new record", second)
+		}
+		users, err := repos.Users.FindAll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 2 {
+			t.Fatalf("FindAll returned %d users, want 2", len(users))
+		}
+	})
+}
+
+// concurrentSaves mensagens do mesmo documento processadas ao mesmo tempo
+const concurrentSaves = 8
+
+// saveConcurrently salva o mesmo documento em concurrentSaves goroutines
+func saveConcurrently(ctx context.Context, users repositories.UserRepository) ([]*repositories.SaveResult, []error) {
+	results := make([]*repositories.SaveResult, concurrentSaves)
+	errs := make([]error, concurrentSaves)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentSaves; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = users.Save(ctx, newUser(fmt.Sprintf("Maria %d", i), "52998224725", "Campinas"))
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return results, errs
+}
+
+// testConcurrentSave garante que a deduplicação vale também para inserções
+// simultâneas, que dependem do índice único de documento no MongoDB
+func testConcurrentSave(t *testing.T, newRepos Factory) {
+	t.Run("upsert", func(t *testing.T) {
+		repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert})
+		ctx := tenantContext(entities.DefaultTenant)
+
+		results, errs := saveConcurrently(ctx, repos.Users)
+		created := 0
+		for i, err := range errs {
+			if err != nil {
+				t.Fatalf("concurrent upsert %d: %v", i, err)
+			}
+			if results[i].Created {
+				created++
+			}
+		}
+		if created != 1 {
+			t.Fatalf("%d concurrent upserts created the record, want 1", created)
+		}
+
+		users, err := repos.Users.FindAll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 1 {
+			t.Fatalf("concurrent upserts left %d records, want 1", len(users))
+		}
+		if users[0].Version != concurrentSaves || len(users[0].History) != concurrentSaves {
+			t.Fatalf("merged record has version %d and %d history records, want %d of each",
+				users[0].Version, len(users[0].History), concurrentSaves)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		repos := newRepos(t, Options{DedupePolicy: repositories.DedupeReject})
+		ctx := tenantContext(entities.DefaultTenant)
+
+		_, errs := saveConcurrently(ctx, repos.Users)
+		saved := 0
+		for i, err := range errs {
+			switch {
+			case err == nil:
+				saved++
+			case !errors.Is(err, repositories.ErrDuplicate):
+				t.Fatalf("concurrent insert %d: error = %v, want ErrDuplicate", i, err)
+			}
+		}
+		if saved != 1 {
+			t.Fatalf("%d concurrent inserts succeeded, want 1", saved)
+		}
+	})
+}
+
+func testFindKeyset(t *testing.T, newRepos Factory) {
+	repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert})
+	ctx := tenantContext(entities.DefaultTenant)
+
+	names := []string{"Diego Alves", "Ana Costa", "Eva Martins", "Bruno Lima", "Carla Souza"}
+	documents := []string{"52998224725", "11144477735", "39053344705", "86288366757", "71428793860"}
+	var inserted []string
+	for i, name := range names {
+		inserted = append(inserted, save(t, ctx, repos.Users, newUser(name, documents[i], "Campinas")).ID)
+	}
+	// Registro de outro tenant, que não pode aparecer nas páginas
+	save(t, tenantContext(sharedTenant), repos.Users, newUser("Aaron Outro", "52998224725", "Campinas"))
+
+	collect := func(query repositories.UserQuery) ([]entities.ProcessedUser, int) {
+		t.Helper()
+		var all []entities.ProcessedUser
+		pages := 0
+		for {
+			page, err := repos.Users.Find(ctx, query)
+			if err != nil {
+				t.Fatalf("Find: %v", err)
+			}
+			pages++
+			all = append(all, page.Items...)
+			if page.NextCursor == "" {
+				return all, pages
+			}
+			if pages > len(names) {
+				t.Fatal("pagination does not terminate")
+			}
+			query.Cursor = page.NextCursor
+		}
+	}
+
+	byName, pages := collect(repositories.UserQuery{Limit: 2, SortBy: repositories.SortByName, SortOrder: repositories.SortAscending})
+	want := []string{"Ana Costa", "Bruno Lima", "Carla Souza", "Diego Alves", "Eva Martins"}
+	if len(byName) != len(want) || pages != 3 {
+		t.Fatalf("paging by name returned %d users in %d pages, want %d in 3", len(byName), pages, len(want))
+	}
+	for i, user := range byName {
+		if user.Name != want[i] {
+			t.Fatalf("user %d = %q, want %q", i, user.Name, want[i])
+		}
+	}
+
+	// Padrão: created_at decrescente, empates pelo ID
+	byCreation, _ := collect(repositories.UserQuery{Limit: 2})
+	if len(byCreation) != len(inserted) {
+		t.Fatalf("paging by creation returned %d users, want %d", len(byCreation), len(inserted))
+	}
+	for i, user := range byCreation {
+		if id := inserted[len(inserted)-1-i]; user.ID.Hex() != id {
+			t.Fatalf("user %d = %s (%s), want %s", i, user.ID.Hex(), user.Name, id)
+		}
+	}
+
+	first, err := repos.Users.Find(ctx, repositories.UserQuery{Limit: 2, SortBy: repositories.SortByName, SortOrder: repositories.SortAscending})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Users.Find(ctx, repositories.UserQuery{Limit: 2, Cursor: first.NextCursor}); !errors.Is(err, repositories.ErrInvalidCursor) {
+		t.Fatalf("cursor from another sort: error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+// seedFilterUsers grava três usuários do tenant padrão que diferem em status,
+// validade do documento, estado e cidade, e um de outro tenant igual ao primeiro.
+// Retorna o instante entre a criação do primeiro e a dos demais.
+func seedFilterUsers(t *testing.T, ctx context.Context, users repositories.UserRepository) time.Time {
+	t.Helper()
+
+	save(t, ctx, users, newUser("Ana Costa", "52998224725", "Campinas"))
+	// created_at tem precisão de milissegundos no MongoDB
+	time.Sleep(10 * time.Millisecond)
+	between := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	bruno := newUser("Bruno Lima", "11144477735", "Campinas")
+	bruno.Status = "failed"
+	bruno.Document.IsValid = false
+	save(t, ctx, users, bruno)
+
+	carla := newUser("Carla Souza", "39053344705", "Rio de Janeiro")
+	carla.Address.State = "RJ"
+	carla.Document.IsValid = false
+	save(t, ctx, users, carla)
+
+	save(t, tenantContext(sharedTenant), users, newUser("Ana Outra", "52998224725", "Campinas"))
+	return between
+}
+
+func testFindFilters(t *testing.T, newRepos Factory) {
+	repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert, Tenancy: MultiTenancy()})
+	ctx := tenantContext(entities.DefaultTenant)
+	between := seedFilterUsers(t, ctx, repos.Users)
+
+	valid, invalid := true, false
+	tests := []struct {
+		name  string
+		query repositories.UserQuery
+		want  []string
+	}{
+		{name: "no filter", want: []string{"Ana Costa", "Bruno Lima", "Carla Souza"}},
+		{name: "status", query: repositories.UserQuery{Status: "processed"}, want: []string{"Ana Costa", "Carla Souza"}},
+		{name: "valid documents", query: repositories.UserQuery{DocumentValid: &valid}, want: []string{"Ana Costa"}},
+		{name: "invalid documents", query: repositories.UserQuery{DocumentValid: &invalid}, want: []string{"Bruno Lima", "Carla Souza"}},
+		{name: "state", query: repositories.UserQuery{State: "SP"}, want: []string{"Ana Costa", "Bruno Lima"}},
+		{name: "city", query: repositories.UserQuery{City: "Rio de Janeiro"}, want: []string{"Carla Souza"}},
+		{name: "combined", query: repositories.UserQuery{Status: "failed", State: "SP", City: "Campinas"}, want: []string{"Bruno Lima"}},
+		{name: "created from", query: repositories.UserQuery{CreatedFrom: between}, want: []string{"Bruno Lima", "Carla Souza"}},
+		{name: "created before", query: repositories.UserQuery{CreatedTo: between}, want: []string{"Ana Costa"}},
+		{name: "empty", query: repositories.UserQuery{Status: "processed", State: "RJ", DocumentValid: &valid}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			query.SortBy, query.SortOrder = repositories.SortByName, repositories.SortAscending
+			page, err := repos.Users.Find(ctx, query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, user := range page.Items {
+				got = append(got, user.Name)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("Find = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := repos.Users.Find(ctx, repositories.UserQuery{CreatedFrom: between, CreatedTo: between.Add(-time.Hour)}); !errors.Is(err, repositories.ErrInvalidQuery) {
+		t.Fatalf("Find with an inverted range: error = %v, want ErrInvalidQuery", err)
+	}
+}
+
+// testForEach garante a ordem da exportação e da retenção, sem o limite de página
+func testForEach(t *testing.T, newRepos Factory) {
+	repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert, Tenancy: MultiTenancy()})
+	ctx := tenantContext(entities.DefaultTenant)
+
+	names := []string{"Diego Alves", "Ana Costa", "Eva Martins", "Bruno Lima", "Carla Souza"}
+	documents := []string{"52998224725", "11144477735", "39053344705", "86288366757", "71428793860"}
+	for i, name := range names {
+		save(t, ctx, repos.Users, newUser(name, documents[i], "Campinas"))
+	}
+	save(t, tenantContext(sharedTenant), repos.Users, newUser("Aaron Outro", "52998224725", "Campinas"))
+
+	each := func(query repositories.UserQuery) []string {
+		t.Helper()
+		var got []string
+		err := repos.Users.ForEach(ctx, query, func(user *entities.ProcessedUser) error {
+			got = append(got, user.Name)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ForEach: %v", err)
+		}
+		return got
+	}
+
+	// Limit é ignorado: a exportação percorre tudo
+	byName := each(repositories.UserQuery{Limit: 2, SortBy: repositories.SortByName, SortOrder: repositories.SortAscending})
+	if want := []string{"Ana Costa", "Bruno Lima", "Carla Souza", "Diego Alves", "Eva Martins"}; fmt.Sprint(byName) != fmt.Sprint(want) {
+		t.Fatalf("ForEach by name = %v, want %v", byName, want)
+	}
+	// A retenção percorre do mais antigo para o mais novo
+	byCreation := each(repositories.UserQuery{SortBy: repositories.SortByCreatedAt, SortOrder: repositories.SortAscending})
+	if fmt.Sprint(byCreation) != fmt.Sprint(names) {
+		t.Fatalf("ForEach by creation = %v, want %v", byCreation, names)
+	}
+	if filtered := each(repositories.UserQuery{SortBy: repositories.SortByName, SortOrder: repositories.SortDescending, Status: "processed", City: "Campinas"}); len(filtered) != len(names) || filtered[0] != "Eva Martins" {
+		t.Fatalf("ForEach by name descending = %v, want the %d users from Eva Martins on", filtered, len(names))
+	}
+
+	// Um erro de fn interrompe a leitura e é devolvido
+	stop := errors.New("stop")
+	visited := 0
+	err := repos.Users.ForEach(ctx, repositories.UserQuery{}, func(*entities.ProcessedUser) error {
+		visited++
+		return stop
+	})
+	if !errors.Is(err, stop) || visited != 1 {
+		t.Fatalf("ForEach after fn failed: error = %v, %d users visited; want the fn error after 1", err, visited)
+	}
+}
+
+func testStats(t *testing.T, newRepos Factory) {
+	repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert, Tenancy: MultiTenancy()})
+	ctx := tenantContext(entities.DefaultTenant)
+
+	from := time.Now().Add(-time.Minute)
+	seedFilterUsers(t, ctx, repos.Users)
+	// Reprocessamento: conta no volume, não no total
+	save(t, ctx, repos.Users, newUser("Ana Costa", "52998224725", "Campinas"))
+
+	stats, err := repos.Users.Stats(ctx, repositories.StatsQuery{From: from, To: time.Now().Add(time.Minute), Interval: repositories.IntervalHour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Total != 3 {
+		t.Fatalf("total = %d, want 3", stats.Total)
+	}
+	if want := []repositories.CountBucket{{Key: "processed", Count: 2}, {Key: "failed", Count: 1}}; fmt.Sprint(stats.ByStatus) != fmt.Sprint(want) {
+		t.Fatalf("by status = %v, want %v", stats.ByStatus, want)
+	}
+	if want := (repositories.DocumentStats{Valid: 1, Invalid: 2}); stats.Documents != want {
+		t.Fatalf("documents = %+v, want %+v", stats.Documents, want)
+	}
+	if want := []repositories.CountBucket{{Key: "SP", Count: 2}, {Key: "RJ", Count: 1}}; fmt.Sprint(stats.ByState) != fmt.Sprint(want) {
+		t.Fatalf("by state = %v, want %v", stats.ByState, want)
+	}
+	wantCities := []repositories.CityCount{{State: "SP", City: "Campinas", Count: 2}, {State: "RJ", City: "Rio de Janeiro", Count: 1}}
+	if fmt.Sprint(stats.ByCity) != fmt.Sprint(wantCities) {
+		t.Fatalf("by city = %v, want %v", stats.ByCity, wantCities)
+	}
+	var volume int64
+	for _, bucket := range stats.Volume {
+		volume += bucket.Count
+	}
+	if volume != 4 {
+		t.Fatalf("volume = %d processings in %v, want 4", volume, stats.Volume)
+	}
+
+	if _, err := repos.Users.Stats(ctx, repositories.StatsQuery{From: from, To: from.Add(-time.Hour)}); !errors.Is(err, repositories.ErrInvalidQuery) {
+		t.Fatalf("Stats with an inverted range: error = %v, want ErrInvalidQuery", err)
+	}
+}
+
+func testVersionConflict(t *testing.T, newRepos Factory) {
+	repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert})
+	ctx := tenantContext(entities.DefaultTenant)
+
+	id := save(t, ctx, repos.Users, newUser("Maria da Silva", "52998224725", "Campinas")).ID
+	stale := findByID(t, ctx, repos.Users, id).Version
+
+	name := "Maria Silva"
+	patched, err := repos.Users.Patch(ctx, id, entities.ProcessedUserPatch{Name: &name}, stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Version != stale+1 || patched.Name != name {
+		t.Fatalf("patched = version %d, name %q; want version %d, name %q", patched.Version, patched.Name, stale+1, name)
+	}
+
+	other := "Maria S."
+	if _, err := repos.Users.Patch(ctx, id, entities.ProcessedUserPatch{Name: &other}, stale); !errors.Is(err, repositories.ErrVersionConflict) {
+		t.Fatalf("patch with a stale version: error = %v, want ErrVersionConflict", err)
+	}
+	if user := findByID(t, ctx, repos.Users, id); user.Name != name {
+		t.Fatalf("name after a rejected patch = %q, want %q", user.Name, name)
+	}
+	if _, err := repos.Users.Patch(ctx, id, entities.ProcessedUserPatch{Name: &other}, repositories.AnyVersion); err != nil {
+		t.Fatalf("patch with AnyVersion: %v", err)
+	}
+
+	if err := repos.Users.Delete(ctx, id, stale); !errors.Is(err, repositories.ErrVersionConflict) {
+		t.Fatalf("delete with a stale version: error = %v, want ErrVersionConflict", err)
+	}
+	if err := repos.Users.Delete(ctx, id, stale+2); err != nil {
+		t.Fatalf("delete with the current version: %v", err)
+	}
+	if _, err := repos.Users.FindByID(ctx, id); !errors.Is(err, repositories.ErrNotFound) {
+		t.Fatalf("FindByID after delete: error = %v, want ErrNotFound", err)
+	}
+	if _, err := repos.Users.FindByID(ctx, "not-an-id"); !errors.Is(err, repositories.ErrInvalidID) {
+		t.Fatalf("FindByID with a malformed ID: error = %v, want ErrInvalidID", err)
+	}
+}
+
+// testDeleteVersions cobre a remoção da retenção: só sai o registro que continua na
+// versão arquivada, e só ele ganha evento de remoção
+func testDeleteVersions(t *testing.T, newRepos Factory) {
+	repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert})
+	ctx := tenantContext(entities.DefaultTenant)
+
+	archived := save(t, ctx, repos.Users, newUser("Maria da Silva", "52998224725", "Campinas")).ID
+	changed := save(t, ctx, repos.Users, newUser("João Souza", "39053344705", "Recife")).ID
+	refs := []repositories.UserVersion{
+		{ID: archived, Version: findByID(t, ctx, repos.Users, archived).Version},
+		{ID: changed, Version: findByID(t, ctx, repos.Users, changed).Version},
+		{ID: archived, Version: findByID(t, ctx, repos.Users, archived).Version},
+	}
+
+	// Alterado entre o arquivamento e a remoção
+	name := "João S."
+	if _, err := repos.Users.Patch(ctx, changed, entities.ProcessedUserPatch{Name: &name}, repositories.AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := repos.Users.DeleteVersions(ctx, refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Fatalf("DeleteVersions removed %d users, want 1", deleted)
+	}
+	if _, err := repos.Users.FindByID(ctx, archived); !errors.Is(err, repositories.ErrNotFound) {
+		t.Fatalf("FindByID of the archived version: error = %v, want ErrNotFound", err)
+	}
+	if user := findByID(t, ctx, repos.Users, changed); user.Name != name {
+		t.Fatalf("user changed after archiving = %q, want it kept with %q", user.Name, name)
+	}
+
+	for id, want := range map[string]int{archived: 1, changed: 0} {
+		events, err := repos.Audit.FindByUser(ctx, id, repositories.DefaultHistoryLimit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		deletes := 0
+		for _, event := range events {
+			if event.Action == entities.AuditDelete {
+				deletes++
+			}
+		}
+		if deletes != want {
+			t.Fatalf("user %s has %d delete events, want %d", id, deletes, want)
+		}
+	}
+}
+
+func testEraseSubject(t *testing.T, newRepos Factory) {
+	repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert})
+	ctx := tenantContext(entities.DefaultTenant)
+
+	const (
+		oldDocument = "52998224725"
+		newDocument = "11144477735"
+	)
+	id := save(t, ctx, repos.Users, newUser("Maria da Silva", oldDocument, "Campinas")).ID
+
+	// A trilha de auditoria guarda o documento anterior à correção
+	corrected := newDocument
+	if _, err := repos.Users.Patch(ctx, id, entities.ProcessedUserPatch{Document: &entities.DocumentPatch{DocumentNumber: &corrected}}, repositories.AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	if affected, err := repos.Users.EraseSubject(ctx, oldDocument, repositories.ErasureAnonymize); err != nil || affected != 0 {
+		t.Fatalf("erasing the previous document from users affected %d (err %v), want 0", affected, err)
+	}
+	affected, err := repos.Audit.EraseSubject(ctx, "529.982.247-25", repositories.ErasureAnonymize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected != 2 {
+		t.Fatalf("erasing the previous document redacted %d audit events, want 2 (create and patch)", affected)
+	}
+	events, err := repos.Audit.FindByUser(ctx, id, repositories.DefaultHistoryLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range events {
+		if !event.Redacted || len(event.Changes) != 0 {
+			t.Fatalf("%s event still holds the erased subject: %+v", event.Action, event)
+		}
+	}
+
+	// Outro tenant não alcança o titular
+	if affected, err := repos.Users.EraseSubject(tenantContext(sharedTenant), newDocument, repositories.ErasureDelete); err != nil || affected != 0 {
+		t.Fatalf("erasure from another tenant affected %d (err %v), want 0", affected, err)
+	}
+
+	affected, err = repos.Users.EraseSubject(ctx, newDocument, repositories.ErasureAnonymize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected != 1 {
+		t.Fatalf("anonymized %d users, want 1", affected)
+	}
+	user := findByID(t, ctx, repos.Users, id)
+	if user.Name != entities.ErasedPlaceholder || user.Status != entities.ErasedStatus ||
+		user.Document.DocumentNumber == newDocument || user.Address.Street != "" || len(user.History) != 0 {
+		t.Fatalf("anonymized user still holds personal data: %+v", user)
+	}
+
+	deleted := save(t, ctx, repos.Users, newUser("João Souza", "39053344705", "Recife")).ID
+	if affected, err := repos.Users.EraseSubject(ctx, "390.533.447-05", repositories.ErasureDelete); err != nil || affected != 1 {
+		t.Fatalf("deleting the subject affected %d (err %v), want 1", affected, err)
+	}
+	if _, err := repos.Users.FindByID(ctx, deleted); !errors.Is(err, repositories.ErrNotFound) {
+		t.Fatalf("FindByID after erasure: error = %v, want ErrNotFound", err)
+	}
+}
+
+func testSearch(t *testing.T, newRepos Factory) {
+	repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert})
+	ctx := tenantContext(entities.DefaultTenant)
+
+	save(t, ctx, repos.Users, newUser("Maria da Silva", "52998224725", "Campinas"))
+	save(t, ctx, repos.Users, newUser("João Souza", "11144477735", "Recife"))
+	save(t, ctx, repos.Users, newUser("Mariana Lima", "39053344705", "Campinas"))
+	save(t, tenantContext(sharedTenant), repos.Users, newUser("Pedro Souza", "86288366757", "Campinas"))
+
+	search := func(ctx context.Context, query repositories.SearchQuery) []string {
+		t.Helper()
+		var names []string
+		for pages := 0; ; pages++ {
+			if pages > 10 {
+				t.Fatal("search pagination does not terminate")
+			}
+			page, err := repos.Users.Search(ctx, query)
+			if err != nil {
+				t.Fatalf("Search(%q): %v", query.Text, err)
+			}
+			for _, user := range page.Items {
+				names = append(names, user.Name)
+			}
+			if page.NextCursor == "" {
+				return names
+			}
+			query.Cursor = page.NextCursor
+		}
+	}
+
+	if names := search(ctx, repositories.SearchQuery{Text: "Souza"}); len(names) != 1 || names[0] != "João Souza" {
+		t.Fatalf("search for Souza = %v, want only the tenant's record", names)
+	}
+	if names := search(ctx, repositories.SearchQuery{Text: "Campinas", Limit: 1}); len(names) != 2 || names[0] == names[1] {
+		t.Fatalf("paged search for Campinas = %v, want both records once", names)
+	}
+	// Sem palavra inteira, a busca recorre aos prefixos
+	if names := search(ctx, repositories.SearchQuery{Text: "jo sou"}); len(names) != 1 || names[0] != "João Souza" {
+		t.Fatalf("partial search = %v, want João Souza", names)
+	}
+	if _, err := repos.Users.Search(ctx, repositories.SearchQuery{Text: "a"}); !errors.Is(err, repositories.ErrInvalidQuery) {
+		t.Fatalf("search for a single character: error = %v, want ErrInvalidQuery", err)
+	}
+
+	// Registros anonimizados ficam fora da busca
+	if _, err := repos.Users.EraseSubject(ctx, "52998224725", repositories.ErasureAnonymize); err != nil {
+		t.Fatal(err)
+	}
+	if names := search(ctx, repositories.SearchQuery{Text: "Campinas"}); len(names) != 1 || names[0] != "Mariana Lima" {
+		t.Fatalf("search after erasure = %v, want Mariana Lima", names)
+	}
+}
+
+// watchTimeout limite de espera por uma alteração que deveria chegar
+const watchTimeout = 10 * time.Second
+
+func watch(t *testing.T, ctx context.Context, users repositories.UserRepository, query repositories.WatchQuery) repositories.UserChangeStream {
+	t.Helper()
+	stream, err := users.Watch(ctx, query)
+	if errors.Is(err, repositories.ErrWatchUnsupported) {
+		t.Skip("change streams require a replica set")
+	}
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	t.Cleanup(func() { stream.Close(context.Background()) })
+	return stream
+}
+
+// expectChange lê a próxima alteração e verifica o tipo e o registro
+func expectChange(t *testing.T, stream repositories.UserChangeStream, changeType repositories.ChangeType, userID string) *repositories.UserChange {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), watchTimeout)
+	defer cancel()
+
+	change, err := stream.Next(ctx)
+	if err != nil {
+		t.Fatalf("waiting for %s of %s: %v", changeType, userID, err)
+	}
+	if change.Type != changeType || change.UserID != userID {
+		t.Fatalf("change = %s of %s, want %s of %s", change.Type, change.UserID, changeType, userID)
+	}
+	if changeType != repositories.ChangeDelete && (change.User == nil || change.User.ID.Hex() != userID) {
+		t.Fatalf("%s of %s carries no current document", changeType, userID)
+	}
+	return change
+}
+
+func testWatch(t *testing.T, newRepos Factory) {
+	t.Run("single tenant", func(t *testing.T) {
+		repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert})
+		ctx := tenantContext(entities.DefaultTenant)
+		stream := watch(t, ctx, repos.Users, repositories.WatchQuery{})
+
+		id := save(t, ctx, repos.Users, newUser("Maria da Silva", "52998224725", "Campinas")).ID
+		inserted := expectChange(t, stream, repositories.ChangeInsert, id)
+
+		name := "Maria Silva"
+		if _, err := repos.Users.Patch(ctx, id, entities.ProcessedUserPatch{Name: &name}, repositories.AnyVersion); err != nil {
+			t.Fatal(err)
+		}
+		if change := expectChange(t, stream, repositories.ChangeUpdate, id); change.User.Name != name {
+			t.Fatalf("update carries name %q, want %q", change.User.Name, name)
+		}
+		if err := repos.Users.Delete(ctx, id, repositories.AnyVersion); err != nil {
+			t.Fatal(err)
+		}
+		expectChange(t, stream, repositories.ChangeDelete, id)
+
+		// Retomada logo após a criação
+		resumed := watch(t, ctx, repos.Users, repositories.WatchQuery{ResumeToken: inserted.ResumeToken})
+		expectChange(t, resumed, repositories.ChangeUpdate, id)
+		expectChange(t, resumed, repositories.ChangeDelete, id)
+
+		// Filtro por status: a remoção não tem status e não é entregue
+		filtered := watch(t, ctx, repos.Users, repositories.WatchQuery{Status: "processed"})
+		failed := newUser("João Souza", "11144477735", "Recife")
+		failed.Status = "failed"
+		failedID := save(t, ctx, repos.Users, failed).ID
+		if err := repos.Users.Delete(ctx, failedID, repositories.AnyVersion); err != nil {
+			t.Fatal(err)
+		}
+		processedID := save(t, ctx, repos.Users, newUser("Mariana Lima", "39053344705", "Campinas")).ID
+		expectChange(t, filtered, repositories.ChangeInsert, processedID)
+
+		if _, err := repos.Users.Watch(ctx, repositories.WatchQuery{ResumeToken: "not-a-token"}); !errors.Is(err, repositories.ErrInvalidResumeToken) {
+			t.Fatalf("Watch with a malformed token: error = %v, want ErrInvalidResumeToken", err)
+		}
+	})
+
+	t.Run("multi tenant", func(t *testing.T) {
+		repos := newRepos(t, Options{DedupePolicy: repositories.DedupeUpsert, Tenancy: MultiTenancy()})
+		defaultCtx := tenantContext(entities.DefaultTenant)
+		sharedCtx := tenantContext(sharedTenant)
+		dedicatedCtx := tenantContext(dedicatedTenant)
+
+		legacy := newUser("Registro Legado", "71428793860", "Campinas")
+		repos.InsertLegacy(t, legacy)
+
+		defaultStream := watch(t, defaultCtx, repos.Users, repositories.WatchQuery{})
+		sharedStream := watch(t, sharedCtx, repos.Users, repositories.WatchQuery{})
+		dedicatedStream := watch(t, dedicatedCtx, repos.Users, repositories.WatchQuery{})
+
+		// Registros sem tenant pertencem ao tenant padrão, também na observação
+		name := "Registro Migrado"
+		if _, err := repos.Users.Patch(defaultCtx, legacy.ID.Hex(), entities.ProcessedUserPatch{Name: &name}, repositories.AnyVersion); err != nil {
+			t.Fatal(err)
+		}
+		expectChange(t, defaultStream, repositories.ChangeUpdate, legacy.ID.Hex())
+
+		// Na collection compartilhada a remoção chega só ao tenant do registro
+		sharedID := save(t, sharedCtx, repos.Users, newUser("Maria da Silva", "52998224725", "Campinas")).ID
+		expectChange(t, sharedStream, repositories.ChangeInsert, sharedID)
+		if err := repos.Users.Delete(sharedCtx, sharedID, repositories.AnyVersion); err != nil {
+			t.Fatal(err)
+		}
+		expectChange(t, sharedStream, repositories.ChangeDelete, sharedID)
+		otherID := save(t, defaultCtx, repos.Users, newUser("Outro Tenant", "86288366757", "Campinas")).ID
+		if err := repos.Users.Delete(defaultCtx, otherID, repositories.AnyVersion); err != nil {
+			t.Fatal(err)
+		}
+		nextID := save(t, sharedCtx, repos.Users, newUser("João Souza", "11144477735", "Recife")).ID
+		expectChange(t, sharedStream, repositories.ChangeInsert, nextID)
+
+		// Com banco dedicado também
+		dedicatedID := save(t, dedicatedCtx, repos.Users, newUser("Maria da Silva", "52998224725", "Campinas")).ID
+		expectChange(t, dedicatedStream, repositories.ChangeInsert, dedicatedID)
+		if err := repos.Users.Delete(dedicatedCtx, dedicatedID, repositories.AnyVersion); err != nil {
+			t.Fatal(err)
+		}
+		expectChange(t, dedicatedStream, repositories.ChangeDelete, dedicatedID)
+	})
+}
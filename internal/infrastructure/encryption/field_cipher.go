@@ -0,0 +1,123 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Formato do valor cifrado: enc:v1:<key-id>:<base64(nonce || ciphertext)>
+const (
+	encryptedPrefix = "enc:v1:"
+	keySize         = 32 // AES-256
+)
+
+// FieldCipher cifra campos individuais com AES-GCM e gera índices cegos (HMAC-SHA256)
+// para permitir buscas por igualdade sem decifrar. Cada valor cifrado carrega o ID
+// da chave usada, então chaves antigas continuam decifrando após uma rotação.
+type FieldCipher struct {
+	keys        map[string]cipher.AEAD
+	activeKeyID string
+	blindKey    []byte
+}
+
+// NewFieldCipher cria o cifrador. keys mapeia ID para chave de 32 bytes; novas
+// cifragens usam activeKeyID.
+func NewFieldCipher(keys map[string][]byte, activeKeyID string, blindIndexKey []byte) (*FieldCipher, error) {
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active encryption key %q not found", activeKeyID)
+	}
+	if len(blindIndexKey) < keySize {
+		return nil, fmt.Errorf("blind index key must have at least %d bytes", keySize)
+	}
+
+	aeads := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid encryption key ID %q", id)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("encryption key %q must have %d bytes", id, keySize)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %v", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %v", id, err)
+		}
+		aeads[id] = aead
+	}
+
+	return &FieldCipher{
+		keys:        aeads,
+		activeKeyID: activeKeyID,
+		blindKey:    blindIndexKey,
+	}, nil
+}
+
+// Encrypt cifra plaintext com a chave ativa. field entra como dado autenticado,
+// impedindo que um valor cifrado seja copiado para outro campo.
+func (c *FieldCipher) Encrypt(field, plaintext string) (string, error) {
+	aead := c.keys[c.activeKeyID]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(field))
+	return encryptedPrefix + c.activeKeyID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decifra um valor gerado por Encrypt. Valores sem o prefixo de cifragem
+// (gravados antes da criptografia ser ativada) são retornados sem alteração.
+func (c *FieldCipher) Decrypt(field, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	keyID, payload, ok := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !ok {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	aead, ok := c.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("encryption key %q not available", keyID)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s with key %q: %v", field, keyID, err)
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex retorna um HMAC determinístico de value, usado para buscas e unicidade
+func (c *FieldCipher) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, c.blindKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ActiveKeyID retorna o ID da chave usada nas novas cifragens
+func (c *FieldCipher) ActiveKeyID() string {
+	return c.activeKeyID
+}
+
+// IsEncrypted indica se value foi gerado por Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
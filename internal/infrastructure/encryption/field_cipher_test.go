@@ -0,0 +1,190 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, keySize)
+}
+
+func newTestCipher(t *testing.T, keys map[string][]byte, active string) *FieldCipher {
+	t.Helper()
+	c, err := NewFieldCipher(keys, active, testKey('b'))
+	if err != nil {
+		t.Fatalf("NewFieldCipher: %v", err)
+	}
+	return c
+}
+
+func TestFieldCipherRoundTrip(t *testing.T) {
+	c := newTestCipher(t, map[string][]byte{"k1": testKey(1)}, "k1")
+
+	for _, plaintext := range []string{"12345678909", "", "ção"} {
+		encrypted, err := c.Encrypt("document.document_number", plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt(%q): %v", plaintext, err)
+		}
+		if !IsEncrypted(encrypted) || !strings.HasPrefix(encrypted, "enc:v1:k1:") {
+			t.Fatalf("Encrypt(%q) = %q, want the enc:v1:k1: prefix", plaintext, encrypted)
+		}
+		if plaintext != "" && strings.Contains(encrypted, plaintext) {
+			t.Fatalf("Encrypt(%q) = %q leaks the plaintext", plaintext, encrypted)
+		}
+
+		decrypted, err := c.Decrypt("document.document_number", encrypted)
+		if err != nil {
+			t.Fatalf("Decrypt: %v", err)
+		}
+		if decrypted != plaintext {
+			t.Errorf("Decrypt = %q, want %q", decrypted, plaintext)
+		}
+	}
+
+	// Nonce aleatório: o mesmo valor não gera o mesmo texto cifrado
+	first, _ := c.Encrypt("field", "12345678909")
+	second, _ := c.Encrypt("field", "12345678909")
+	if first == second {
+		t.Error("two encryptions of the same value are identical")
+	}
+}
+
+func TestFieldCipherDecryptPassesPlainTextThrough(t *testing.T) {
+	c := newTestCipher(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	got, err := c.Decrypt("field", "12345678909")
+	if err != nil || got != "12345678909" {
+		t.Fatalf("Decrypt(plain) = %q, %v; want the value unchanged", got, err)
+	}
+}
+
+func TestFieldCipherRejectsOtherField(t *testing.T) {
+	c := newTestCipher(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	encrypted, err := c.Encrypt("document.document_number", "12345678909")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	if _, err := c.Decrypt("name", encrypted); err == nil {
+		t.Fatal("Decrypt with another field as AAD succeeded, want an authentication error")
+	}
+}
+
+func TestFieldCipherDecryptErrors(t *testing.T) {
+	c := newTestCipher(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	encrypted, err := c.Encrypt("field", "12345678909")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	payload := strings.TrimPrefix(encrypted, "enc:v1:k1:")
+	sealed, _ := base64.StdEncoding.DecodeString(payload)
+	sealed[len(sealed)-1] ^= 0xff
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"unknown key ID", "enc:v1:k9:" + payload, `encryption key "k9" not available`},
+		{"missing key ID", "enc:v1:" + payload, "malformed encrypted value"},
+		{"invalid base64", "enc:v1:k1:not-base64!", "malformed encrypted value"},
+		{"shorter than the nonce", "enc:v1:k1:" + base64.StdEncoding.EncodeToString([]byte("abc")), "malformed encrypted value"},
+		{"tampered ciphertext", "enc:v1:k1:" + base64.StdEncoding.EncodeToString(sealed), "failed to decrypt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.Decrypt("field", tt.value)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Decrypt error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFieldCipherKeyRotation(t *testing.T) {
+	old := newTestCipher(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	encryptedWithOld, err := old.Encrypt("field", "12345678909")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	rotated := newTestCipher(t, map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k2")
+	if rotated.ActiveKeyID() != "k2" {
+		t.Fatalf("ActiveKeyID = %q, want k2", rotated.ActiveKeyID())
+	}
+
+	// A chave antiga continua decifrando os valores gravados antes da rotação
+	if got, err := rotated.Decrypt("field", encryptedWithOld); err != nil || got != "12345678909" {
+		t.Fatalf("Decrypt(k1 value) after rotation = %q, %v", got, err)
+	}
+
+	encryptedWithNew, err := rotated.Encrypt("field", "12345678909")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(encryptedWithNew, "enc:v1:k2:") {
+		t.Fatalf("Encrypt after rotation = %q, want the k2 key ID", encryptedWithNew)
+	}
+	if _, err := old.Decrypt("field", encryptedWithNew); err == nil {
+		t.Fatal("cipher without k2 decrypted a k2 value")
+	}
+
+	// Removida a chave antiga, os valores dela deixam de decifrar
+	retired := newTestCipher(t, map[string][]byte{"k2": testKey(2)}, "k2")
+	if _, err := retired.Decrypt("field", encryptedWithOld); err == nil {
+		t.Fatal("Decrypt of a k1 value succeeded after k1 was removed")
+	}
+}
+
+func TestBlindIndexIsDeterministicAndKeyed(t *testing.T) {
+	c := newTestCipher(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	rotated := newTestCipher(t, map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k2")
+
+	index := c.BlindIndex("12345678909")
+	if len(index) != 64 {
+		t.Fatalf("BlindIndex length = %d, want 64 hex characters", len(index))
+	}
+	if again := c.BlindIndex("12345678909"); again != index {
+		t.Error("BlindIndex is not deterministic")
+	}
+	if c.BlindIndex("12345678900") == index {
+		t.Error("different values share a blind index")
+	}
+	// Independente da chave de cifragem: a rotação não invalida as buscas
+	if rotated.BlindIndex("12345678909") != index {
+		t.Error("blind index changed with the encryption key rotation")
+	}
+
+	otherBlindKey, err := NewFieldCipher(map[string][]byte{"k1": testKey(1)}, "k1", testKey('c'))
+	if err != nil {
+		t.Fatalf("NewFieldCipher: %v", err)
+	}
+	if otherBlindKey.BlindIndex("12345678909") == index {
+		t.Error("blind index does not depend on the blind index key")
+	}
+}
+
+func TestNewFieldCipherValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     map[string][]byte
+		active   string
+		blindKey []byte
+		wantErr  string
+	}{
+		{"active key missing", map[string][]byte{"k1": testKey(1)}, "k2", testKey('b'), `active encryption key "k2" not found`},
+		{"short key", map[string][]byte{"k1": testKey(1)[:16]}, "k1", testKey('b'), "must have 32 bytes"},
+		{"key ID with colon", map[string][]byte{"k1": testKey(1), "a:b": testKey(2)}, "k1", testKey('b'), "invalid encryption key ID"},
+		{"short blind index key", map[string][]byte{"k1": testKey(1)}, "k1", []byte("short"), "blind index key must have at least 32 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFieldCipher(tt.keys, tt.active, tt.blindKey)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewFieldCipher error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
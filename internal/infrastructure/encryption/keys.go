@@ -0,0 +1,72 @@
+package encryption
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"api-rabbitmq/internal/infrastructure/config"
+)
+
+// NewFieldCipherFromConfig cria o cifrador a partir da configuração de privacidade.
+// Retorna nil quando nenhuma chave está configurada (criptografia desativada).
+func NewFieldCipherFromConfig(cfg *config.PrivacyConfig) (*FieldCipher, error) {
+	spec := cfg.EncryptionKeys
+	if cfg.EncryptionKeysFile != "" {
+		content, err := os.ReadFile(cfg.EncryptionKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read encryption keys file: %v", err)
+		}
+		spec = string(content)
+	}
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	keys, err := ParseKeys(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	blindKey, err := base64.StdEncoding.DecodeString(cfg.BlindIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("blind index key must be base64: %v", err)
+	}
+
+	return NewFieldCipher(keys, cfg.EncryptionActiveKeyID, blindKey)
+}
+
+// ParseKeys interpreta chaves no formato "<id>:<base64>", separadas por vírgula ou
+// uma por linha. Linhas iniciadas por "#" são ignoradas.
+func ParseKeys(spec string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+
+	scanner := bufio.NewScanner(strings.NewReader(strings.ReplaceAll(spec, ",", "\n")))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(line, ":")
+		id = strings.TrimSpace(id)
+		if !ok {
+			return nil, fmt.Errorf("encryption key entry must be <id>:<base64>")
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q must be base64: %v", id, err)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("duplicate encryption key %q", id)
+		}
+		keys[id] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no encryption keys found")
+	}
+	return keys, nil
+}
@@ -0,0 +1,75 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"api-rabbitmq/internal/infrastructure/config"
+)
+
+func TestParseKeys(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+
+	keys, err := ParseKeys("# chaves\nk1:" + k1 + "\n\n k2 : " + k2 + " ")
+	if err != nil {
+		t.Fatalf("ParseKeys(lines): %v", err)
+	}
+	if len(keys) != 2 || !bytes.Equal(keys["k1"], testKey(1)) || !bytes.Equal(keys["k2"], testKey(2)) {
+		t.Fatalf("ParseKeys(lines) = %v", keys)
+	}
+	if keys, err := ParseKeys("k1:" + k1 + ",k2:" + k2); err != nil || len(keys) != 2 {
+		t.Fatalf("ParseKeys(comma separated) = %v, %v", keys, err)
+	}
+
+	for spec, wantErr := range map[string]string{
+		"k1":                     "must be <id>:<base64>",
+		"k1:%%%":                 "must be base64",
+		"k1:" + k1 + ",k1:" + k2: `duplicate encryption key "k1"`,
+		"# só comentários":       "no encryption keys found",
+	} {
+		if _, err := ParseKeys(spec); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("ParseKeys(%q) error = %v, want %q", spec, err, wantErr)
+		}
+	}
+}
+
+func TestNewFieldCipherFromConfig(t *testing.T) {
+	blindKey := base64.StdEncoding.EncodeToString(testKey('b'))
+	spec := "k1:" + base64.StdEncoding.EncodeToString(testKey(1))
+
+	disabled, err := NewFieldCipherFromConfig(&config.PrivacyConfig{})
+	if err != nil || disabled != nil {
+		t.Fatalf("NewFieldCipherFromConfig(no keys) = %v, %v; want nil, nil", disabled, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(file, []byte(spec+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewFieldCipherFromConfig(&config.PrivacyConfig{
+		EncryptionKeys:        "ignored:" + base64.StdEncoding.EncodeToString(testKey(9)),
+		EncryptionKeysFile:    file,
+		EncryptionActiveKeyID: "k1",
+		BlindIndexKey:         blindKey,
+	})
+	if err != nil {
+		t.Fatalf("NewFieldCipherFromConfig(file): %v", err)
+	}
+	if _, ok := c.keys["ignored"]; ok || c.ActiveKeyID() != "k1" {
+		t.Fatal("the keys file must take precedence over ENCRYPTION_KEYS")
+	}
+
+	_, err = NewFieldCipherFromConfig(&config.PrivacyConfig{
+		EncryptionKeys:        spec,
+		EncryptionActiveKeyID: "k1",
+		BlindIndexKey:         "not base64!",
+	})
+	if err == nil || !strings.Contains(err.Error(), "blind index key must be base64") {
+		t.Fatalf("invalid blind index key error = %v", err)
+	}
+}
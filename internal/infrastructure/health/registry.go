@@ -0,0 +1,178 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status estado de uma verificação ou do relatório
+type Status string
+
+const (
+	StatusUp Status = "up"
+	// StatusDown uma verificação que falhou
+	StatusDown Status = "down"
+	// StatusDegraded relatório com falhas apenas em verificações não críticas
+	StatusDegraded Status = "degraded"
+	// StatusUnavailable relatório com ao menos uma verificação crítica falhando
+	StatusUnavailable Status = "unavailable"
+)
+
+// CheckFunc executa uma verificação; details, quando não nil, aparece no relatório
+type CheckFunc func(ctx context.Context) (details any, err error)
+
+// Result resultado de uma verificação
+type Result struct {
+	Status    Status  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Details   any     `json:"details,omitempty"`
+	// Cached resultado reaproveitado de uma execução anterior (RegisterCached)
+	Cached bool `json:"cached,omitempty"`
+}
+
+// Report resultado de todas as verificações registradas
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Ready indica se nenhuma verificação crítica falhou
+func (r Report) Ready() bool {
+	return r.Status != StatusUnavailable
+}
+
+type check struct {
+	name     string
+	critical bool
+	run      CheckFunc
+	ttl      time.Duration
+
+	// mu serializa as execuções de uma verificação com cache
+	mu      sync.Mutex
+	cached  *Result
+	expires time.Time
+}
+
+// Registry conjunto de verificações de dependências executadas pelo readiness.
+// Uma falha em verificação crítica torna a instância indisponível; as demais
+// apenas degradam o relatório.
+type Registry struct {
+	mu      sync.RWMutex
+	checks  []*check
+	timeout time.Duration
+}
+
+// NewRegistry cria um registro cujas verificações são interrompidas após timeout
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// Register adiciona uma verificação; nomes repetidos substituem a anterior
+func (r *Registry) Register(name string, critical bool, run CheckFunc) {
+	r.register(&check{name: name, critical: critical, run: run})
+}
+
+// RegisterCached adiciona uma verificação cujo resultado é reaproveitado por ttl,
+// para dependências que não devem receber uma requisição a cada probe (ex: APIs
+// externas). Probes simultâneos aguardam a mesma execução.
+func (r *Registry) RegisterCached(name string, critical bool, ttl time.Duration, run CheckFunc) {
+	r.register(&check{name: name, critical: critical, run: run, ttl: ttl})
+}
+
+func (r *Registry) register(c *check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.checks {
+		if r.checks[i].name == c.name {
+			r.checks[i] = c
+			return
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// Run executa todas as verificações em paralelo, cada uma limitada ao timeout do registro
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]*check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = r.result(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		result := results[i]
+		report.Checks[c.name] = result
+		if result.Status == StatusUp {
+			continue
+		}
+		if c.critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// result executa a verificação, ou devolve o resultado em cache enquanto válido
+func (r *Registry) result(ctx context.Context, c *check) Result {
+	if c.ttl <= 0 {
+		return r.runCheck(ctx, c)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != nil && time.Now().Before(c.expires) {
+		result := *c.cached
+		result.Cached = true
+		return result
+	}
+	result := r.runCheck(ctx, c)
+	// Um probe abandonado pelo cliente não vale como resultado da dependência
+	if ctx.Err() == nil {
+		c.cached = &result
+		c.expires = time.Now().Add(c.ttl)
+	}
+	return result
+}
+
+func (r *Registry) runCheck(ctx context.Context, c *check) (result Result) {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	defer func() {
+		// Uma verificação com panic não derruba o endpoint, só falha
+		if p := recover(); p != nil {
+			result = Result{Status: StatusDown, Critical: c.critical, Error: fmt.Sprintf("check panicked: %v", p)}
+		}
+		result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	}()
+
+	details, err := c.run(ctx)
+	result = Result{Status: StatusUp, Critical: c.critical, Details: details}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
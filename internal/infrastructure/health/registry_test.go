@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegisterCachedReusesResultsUntilTTL(t *testing.T) {
+	var calls atomic.Int32
+	registry := NewRegistry(time.Second)
+	registry.RegisterCached("external_api", false, 50*time.Millisecond, func(ctx context.Context) (any, error) {
+		calls.Add(1)
+		return nil, errors.New("service returned status: 502")
+	})
+
+	first := registry.Run(context.Background())
+	if first.Status != StatusDegraded || first.Checks["external_api"].Cached {
+		t.Fatalf("first run = %+v, want a fresh degraded result", first)
+	}
+	for i := 0; i < 5; i++ {
+		report := registry.Run(context.Background())
+		if result := report.Checks["external_api"]; !result.Cached || result.Error == "" {
+			t.Fatalf("run %d = %+v, want the cached failure", i, result)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("check ran %d times within the TTL, want 1", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if report := registry.Run(context.Background()); report.Checks["external_api"].Cached {
+		t.Fatal("result still cached after the TTL")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("check ran %d times, want 2", got)
+	}
+}
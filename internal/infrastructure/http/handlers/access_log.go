@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/domain/entities"
+)
+
+// AccessLog registra cada requisição pelo template da rota (ex:
+// /api/v1/users/by-document/:document) em vez do caminho recebido, que pode conter
+// documentos, e sem a query string, que pode conter nomes buscados. Substitui o
+// logger padrão do Gin.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		log.Printf("%3d | %13v | %15s | %-7s %s",
+			c.Writer.Status(), time.Since(start), c.ClientIP(), c.Request.Method, logPath(c))
+	}
+}
+
+// logPath caminho da requisição seguro para logs: o template da rota, ou o caminho
+// recebido apenas com o mascaramento de dados pessoais desligado
+func logPath(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	if !entities.PIIMaskingEnabled() {
+		return c.Request.URL.Path
+	}
+	return "(unmatched route)"
+}
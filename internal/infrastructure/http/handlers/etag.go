@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+// etag representa a versão do registro como ETag forte (ex: "3")
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// expectedVersion lê o cabeçalho If-Match. Escritas exigem o cabeçalho; sem ele
+// a resposta é 428 e ok é false. "*" aceita qualquer versão. If-Match usa comparação
+// forte (RFC 9110, seção 13.1.1): uma ETag fraca nunca coincide e a resposta é 412.
+func expectedVersion(c *gin.Context) (version int64, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		writeProblem(c, Problem{
+			Status: http.StatusPreconditionRequired,
+			Code:   "precondition_required",
+			Detail: "If-Match header with the current ETag is required",
+		})
+		return 0, false
+	}
+	if header == "*" {
+		return repositories.AnyVersion, true
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		writeProblem(c, Problem{
+			Status: http.StatusPreconditionFailed,
+			Code:   "weak_etag",
+			Detail: "If-Match requires a strong ETag",
+		})
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil || version < 0 {
+		writeProblem(c, Problem{
+			Status: http.StatusBadRequest,
+			Code:   "invalid_if_match",
+			Detail: "If-Match header must be an ETag returned by this API",
+		})
+		return 0, false
+	}
+	return version, true
+}
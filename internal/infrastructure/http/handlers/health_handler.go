@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/infrastructure/health"
+)
+
+// BrokerConnection estado da conexão com o broker, informado pelo /health legado
+type BrokerConnection interface {
+	IsConnected() bool
+}
+
+type HealthHandler struct {
+	registry *health.Registry
+	broker   BrokerConnection
+}
+
+func NewHealthHandler(registry *health.Registry, broker BrokerConnection) *HealthHandler {
+	return &HealthHandler{
+		registry: registry,
+		broker:   broker,
+	}
+}
+
+// Live indica apenas que o processo responde; falhas de dependências não devem
+// fazer o orquestrador reiniciar a instância
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+// Ready executa as verificações registradas e responde 503 se alguma crítica falhar
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.registry.Run(c.Request.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
+
+// Health mantém a resposta do /health anterior aos probes: sempre 200, com o estado
+// da conexão AMQP. Orquestradores devem usar /livez e /readyz.
+func (h *HealthHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":          "ok",
+		"rabbitmq_status": h.broker.IsConnected(),
+		"message":         "API is running",
+	})
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/application/usecases"
+)
+
+type PrivacyHandler struct {
+	privacyUseCase usecases.PrivacyUseCase
+}
+
+func NewPrivacyHandler(privacyUseCase usecases.PrivacyUseCase) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyUseCase: privacyUseCase,
+	}
+}
+
+func (h *PrivacyHandler) EraseSubject(c *gin.Context) {
+	receipt, err := h.privacyUseCase.EraseSubject(c.Request.Context(), c.Param("document"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Personal data erased",
+		"receipt": receipt,
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/application/usecases"
+	"api-rabbitmq/internal/domain/entities"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypeBase    = "urn:api-rabbitmq:problem:"
+)
+
+// Problem representa uma resposta de erro no formato RFC 7807
+type Problem struct {
+	Type     string                `json:"type"`
+	Title    string                `json:"title"`
+	Status   int                   `json:"status"`
+	Detail   string                `json:"detail,omitempty"`
+	Instance string                `json:"instance,omitempty"`
+	Code     string                `json:"code"`
+	Errors   []entities.FieldError `json:"errors,omitempty"`
+}
+
+var problemStatus = map[usecases.ErrorKind]int{
+	usecases.KindNotFound:              http.StatusNotFound,
+	usecases.KindValidation:            http.StatusBadRequest,
+	usecases.KindDependencyUnavailable: http.StatusServiceUnavailable,
+	usecases.KindConflict:              http.StatusConflict,
+	usecases.KindPreconditionFailed:    http.StatusPreconditionFailed,
+}
+
+// respondError converte err em problem+json. Apenas erros tipados dos casos de uso
+// têm seus detalhes expostos; qualquer outro erro vira um 500 genérico.
+func respondError(c *gin.Context, err error) {
+	var ucErr *usecases.Error
+	if !errors.As(err, &ucErr) {
+		log.Printf("Internal error on %s %s: %v", c.Request.Method, logPath(c), err)
+		writeProblem(c, Problem{
+			Status: http.StatusInternalServerError,
+			Code:   "internal_error",
+			Detail: "An unexpected error occurred",
+		})
+		return
+	}
+
+	status, ok := problemStatus[ucErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	if status >= http.StatusInternalServerError {
+		log.Printf("Request %s %s failed: %v", c.Request.Method, logPath(c), err)
+	}
+
+	writeProblem(c, Problem{
+		Status: status,
+		Code:   ucErr.Code,
+		Detail: ucErr.Message,
+		Errors: ucErr.Fields,
+	})
+}
+
+func writeProblem(c *gin.Context, p Problem) {
+	if p.Type == "" {
+		p.Type = problemTypeBase + p.Code
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
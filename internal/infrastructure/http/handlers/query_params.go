@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+// parseUserQuery lê os parâmetros de listagem:
+// limit, cursor, sort (created_at|name), order (asc|desc), status,
+// document_valid, state, city, created_from e created_to (RFC 3339)
+func parseUserQuery(c *gin.Context) (repositories.UserQuery, error) {
+	var errs entities.ValidationErrors
+	query := repositories.UserQuery{
+		Cursor:    c.Query("cursor"),
+		SortBy:    repositories.SortField(c.Query("sort")),
+		SortOrder: repositories.SortOrder(c.Query("order")),
+		Status:    c.Query("status"),
+		State:     c.Query("state"),
+		City:      c.Query("city"),
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > repositories.MaxPageLimit {
+			errs = append(errs, entities.FieldError{Field: "limit", Message: "must be an integer between 1 and 100"})
+		}
+		query.Limit = limit
+	}
+
+	switch query.SortBy {
+	case "", repositories.SortByCreatedAt, repositories.SortByName:
+	default:
+		errs = append(errs, entities.FieldError{Field: "sort", Message: "must be one of: created_at, name"})
+	}
+
+	switch query.SortOrder {
+	case "", repositories.SortAscending, repositories.SortDescending:
+	default:
+		errs = append(errs, entities.FieldError{Field: "order", Message: "must be one of: asc, desc"})
+	}
+
+	if v := c.Query("document_valid"); v != "" {
+		valid, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, entities.FieldError{Field: "document_valid", Message: "must be true or false"})
+		}
+		query.DocumentValid = &valid
+	}
+
+	for _, p := range []struct {
+		field  string
+		target *time.Time
+	}{
+		{"created_from", &query.CreatedFrom},
+		{"created_to", &query.CreatedTo},
+	} {
+		if v := c.Query(p.field); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				errs = append(errs, entities.FieldError{Field: p.field, Message: "must be an RFC 3339 timestamp"})
+			}
+			*p.target = t
+		}
+	}
+
+	if len(errs) > 0 {
+		return query, errs
+	}
+	return query, nil
+}
+
+// parseStatsQuery lê os parâmetros das estatísticas: from e to (RFC 3339),
+// interval (hour|day) e tz (fuso IANA, ex: America/Sao_Paulo)
+func parseStatsQuery(c *gin.Context) (repositories.StatsQuery, error) {
+	var errs entities.ValidationErrors
+	query := repositories.StatsQuery{
+		Interval: repositories.StatsInterval(c.Query("interval")),
+		Timezone: c.Query("tz"),
+	}
+
+	switch query.Interval {
+	case "", repositories.IntervalHour, repositories.IntervalDay:
+	default:
+		errs = append(errs, entities.FieldError{Field: "interval", Message: "must be one of: hour, day"})
+	}
+
+	if query.Timezone != "" {
+		if _, err := time.LoadLocation(query.Timezone); err != nil {
+			errs = append(errs, entities.FieldError{Field: "tz", Message: "must be an IANA time zone name"})
+		}
+	}
+
+	for _, p := range []struct {
+		field  string
+		target *time.Time
+	}{
+		{"from", &query.From},
+		{"to", &query.To},
+	} {
+		if v := c.Query(p.field); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				errs = append(errs, entities.FieldError{Field: p.field, Message: "must be an RFC 3339 timestamp"})
+			}
+			*p.target = t
+		}
+	}
+
+	if len(errs) > 0 {
+		return query, errs
+	}
+	return query, nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/domain/entities"
+)
+
+const (
+	CorrelationIDHeader = "X-Correlation-ID"
+	APIKeyHeader        = "X-API-Key"
+	maxCorrelationIDLen = 128
+)
+
+// RequestContext anexa ao contexto da requisição o ator e o ID de correlação usados
+// na trilha de auditoria. O ID recebido em X-Correlation-ID é reaproveitado (ou um
+// novo é gerado) e devolvido na resposta. A chave de API nunca é gravada, apenas
+// uma impressão digital dela.
+func RequestContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		correlationID := c.GetHeader(CorrelationIDHeader)
+		if !validCorrelationID(correlationID) {
+			correlationID = entities.NewCorrelationID()
+		}
+		c.Header(CorrelationIDHeader, correlationID)
+
+		actor := entities.AuditActor{Type: entities.ActorAPI, ID: "anonymous"}
+		if key := c.GetHeader(APIKeyHeader); key != "" {
+			sum := sha256.Sum256([]byte(key))
+			actor.ID = "key:" + hex.EncodeToString(sum[:6])
+		}
+
+		ctx := entities.WithAudit(c.Request.Context(), actor, correlationID)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// validCorrelationID aceita apenas IDs curtos e imprimíveis, que podem ir para logs e cabeçalhos
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationIDLen {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/application/usecases"
+)
+
+type RetentionHandler struct {
+	retentionUseCase usecases.RetentionUseCase
+}
+
+func NewRetentionHandler(retentionUseCase usecases.RetentionUseCase) *RetentionHandler {
+	return &RetentionHandler{
+		retentionUseCase: retentionUseCase,
+	}
+}
+
+// Report retorna a simulação (dry-run) do que a política de retenção removeria agora
+func (h *RetentionHandler) Report(c *gin.Context) {
+	report, err := h.retentionUseCase.Report(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
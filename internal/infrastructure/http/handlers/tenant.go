@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/infrastructure/config"
+)
+
+const TenantHeader = "X-Tenant-ID"
+
+// Tenant anexa ao contexto da requisição o tenant dono dos dados. Com a
+// multi-tenancy desligada é sempre o padrão. Ligada, vale o tenant vinculado à
+// chave de API; com vínculos configurados, requisições sem chave vinculada são
+// recusadas. Sem vínculos, vale X-Tenant-ID, que deve ser um tenant conhecido.
+func Tenant(tenancy *config.TenancyConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant := entities.DefaultTenant
+		if tenancy.Enabled {
+			var ok bool
+			if tenant, ok = requestTenant(c, tenancy); !ok {
+				return
+			}
+		}
+
+		ctx := entities.WithTenant(c.Request.Context(), tenant)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// requestTenant resolve o tenant ou responde com o problema e retorna false
+func requestTenant(c *gin.Context, tenancy *config.TenancyConfig) (string, bool) {
+	header := c.GetHeader(TenantHeader)
+
+	if bound, ok := tenancy.TenantForAPIKey(c.GetHeader(APIKeyHeader)); ok {
+		if header != "" && header != bound {
+			writeProblem(c, Problem{
+				Status: http.StatusForbidden,
+				Code:   "tenant_forbidden",
+				Detail: "API key is not allowed to access this tenant",
+			})
+			return "", false
+		}
+		return bound, true
+	}
+	if len(tenancy.APIKeys) > 0 {
+		// Com vínculos configurados, X-Tenant-ID sozinho permitiria escolher o tenant
+		writeProblem(c, Problem{
+			Status: http.StatusUnauthorized,
+			Code:   "api_key_required",
+			Detail: "an API key bound to a tenant is required",
+		})
+		return "", false
+	}
+
+	if header == "" {
+		writeProblem(c, Problem{
+			Status: http.StatusBadRequest,
+			Code:   "tenant_required",
+			Detail: TenantHeader + " header is required",
+		})
+		return "", false
+	}
+	if !tenancy.Known(header) {
+		writeProblem(c, Problem{
+			Status: http.StatusBadRequest,
+			Code:   "invalid_tenant",
+			Detail: "unknown tenant",
+		})
+		return "", false
+	}
+	return header, true
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/application/usecases"
+	"api-rabbitmq/internal/domain/entities"
+)
+
+const (
+	// ExportStatusTrailer informa, ao final da resposta, se a exportação foi concluída
+	// ("complete") ou interrompida por erro ("error"); o status HTTP já foi enviado
+	ExportStatusTrailer = "X-Export-Status"
+	exportFlushEvery    = 500
+)
+
+// exportColumns ordem fixa das colunas do CSV
+var exportColumns = []string{
+	"id", "name", "document_number", "document_valid",
+	"street", "city", "state", "zipcode",
+	"status", "message", "created_at", "updated_at", "version",
+}
+
+type exportEncoder interface {
+	begin() error
+	write(user *entities.ProcessedUser) error
+	end() error
+}
+
+// ExportProcessedUsers exporta em CSV ou NDJSON, lendo direto do cursor do banco.
+// Aceita os mesmos filtros e ordenação da listagem; limit e cursor são ignorados.
+func (h *UserHandler) ExportProcessedUsers(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	var enc exportEncoder
+	var contentType string
+	switch format {
+	case "csv":
+		enc = &csvEncoder{w: csv.NewWriter(c.Writer)}
+		contentType = "text/csv; charset=utf-8"
+	case "ndjson":
+		enc = &ndjsonEncoder{enc: json.NewEncoder(c.Writer)}
+		contentType = "application/x-ndjson"
+	default:
+		respondError(c, usecases.NewValidationError("invalid_query", "query parameters are invalid", entities.ValidationErrors{
+			{Field: "format", Message: "must be one of: csv, ndjson"},
+		}))
+		return
+	}
+
+	query, err := parseUserQuery(c)
+	if err != nil {
+		respondError(c, usecases.NewValidationError("invalid_query", "query parameters are invalid", err))
+		return
+	}
+
+	// O cabeçalho só é enviado no primeiro registro, para que erros iniciais
+	// (ex: banco indisponível) ainda possam ser respondidos como problem+json
+	started := false
+	start := func() error {
+		started = true
+		disableWriteTimeout(c)
+		filename := fmt.Sprintf("processed_users-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Header("Trailer", ExportStatusTrailer)
+		c.Status(http.StatusOK)
+		return enc.begin()
+	}
+
+	rows := 0
+	err = h.userUseCase.ExportProcessedUsers(c.Request.Context(), query, func(user *entities.ProcessedUser) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := enc.write(user); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil && !started {
+		respondError(c, err)
+		return
+	}
+	if !started {
+		if err = start(); err != nil {
+			log.Printf("Export failed: %v", err)
+		}
+	}
+
+	status := "complete"
+	if endErr := enc.end(); err == nil {
+		err = endErr
+	}
+	if err != nil {
+		log.Printf("Export interrupted after %d rows: %v", rows, err)
+		status = "error"
+	}
+	c.Writer.Header().Set(ExportStatusTrailer, status)
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) begin() error {
+	return e.w.Write(exportColumns)
+}
+
+func (e *csvEncoder) write(user *entities.ProcessedUser) error {
+	return e.w.Write([]string{
+		user.ID.Hex(),
+		csvSafe(user.Name),
+		user.Document.DocumentNumber,
+		strconv.FormatBool(user.Document.IsValid),
+		csvSafe(user.Address.Street),
+		csvSafe(user.Address.City),
+		csvSafe(user.Address.State),
+		user.Address.Zipcode,
+		csvSafe(user.Status),
+		csvSafe(user.Message),
+		formatExportTime(user.CreatedAt),
+		formatExportTime(user.UpdatedAt),
+		strconv.FormatInt(user.Version, 10),
+	})
+}
+
+func (e *csvEncoder) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvSafe evita que planilhas interpretem o valor como fórmula
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) begin() error { return nil }
+
+func (e *ndjsonEncoder) write(user *entities.ProcessedUser) error {
+	return e.enc.Encode(user)
+}
+
+func (e *ndjsonEncoder) end() error { return nil }
@@ -1,64 +1,228 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"api-rabbitmq/internal/application/usecases"
 	"api-rabbitmq/internal/domain/entities"
-	"api-rabbitmq/internal/infrastructure/messagebroker/rabbitmq"
+	"api-rabbitmq/internal/domain/repositories"
 )
 
+// UserPublisher enfileira os dados recebidos para processamento assíncrono
+type UserPublisher interface {
+	PublishMessage(ctx context.Context, userData entities.UserData) error
+}
+
 type UserHandler struct {
-	userUseCase     usecases.UserUseCase
-	rabbitMQService *rabbitmq.RabbitMQService
+	userUseCase usecases.UserUseCase
+	publisher   UserPublisher
+	// streams é cancelado por CloseStreams, encerrando os fluxos SSE abertos
+	streams      context.Context
+	closeStreams context.CancelFunc
 }
 
-func NewUserHandler(userUseCase usecases.UserUseCase, rabbitMQService *rabbitmq.RabbitMQService) *UserHandler {
+func NewUserHandler(userUseCase usecases.UserUseCase, publisher UserPublisher) *UserHandler {
+	streams, closeStreams := context.WithCancel(context.Background())
 	return &UserHandler{
-		userUseCase:     userUseCase,
-		rabbitMQService: rabbitMQService,
+		userUseCase:  userUseCase,
+		publisher:    publisher,
+		streams:      streams,
+		closeStreams: closeStreams,
 	}
 }
 
+// CloseStreams encerra os fluxos SSE, que de outro modo prenderiam o desligamento
+// do servidor até o prazo; os clientes reconectam com o último id recebido
+func (h *UserHandler) CloseStreams() {
+	h.closeStreams()
+}
+
 func (h *UserHandler) PublishUser(c *gin.Context) {
 	var userData entities.UserData
 	if err := c.ShouldBindJSON(&userData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		respondError(c, usecases.NewValidationError("invalid_json", "request body is not valid JSON", err))
+		return
+	}
+
+	if err := userData.Validate(); err != nil {
+		respondError(c, usecases.NewValidationError("invalid_user_data", "user data failed validation", err))
 		return
 	}
 
-	if err := h.rabbitMQService.PublishMessage(userData); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.publisher.PublishMessage(c.Request.Context(), userData); err != nil {
+		respondError(c, usecases.NewDependencyUnavailableError("queue_unavailable", "failed to publish message", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Message published successfully",
-		"data":    userData,
+		"data":    userData.Masked(),
 	})
 }
 
 func (h *UserHandler) GetProcessedUsers(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	users, err := h.userUseCase.GetProcessedUsers(ctx)
+	query, err := parseUserQuery(c)
+	if err != nil {
+		respondError(c, usecases.NewValidationError("invalid_query", "query parameters are invalid", err))
+		return
+	}
+
+	page, err := h.userUseCase.ListProcessedUsers(ctx, query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        page.Items,
+		"count":       len(page.Items),
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// SearchProcessedUsers busca por ?q= em nome, logradouro e cidade; aceita limit e cursor
+func (h *UserHandler) SearchProcessedUsers(c *gin.Context) {
+	query := repositories.SearchQuery{
+		Text:   c.Query("q"),
+		Cursor: c.Query("cursor"),
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > repositories.MaxPageLimit {
+			respondError(c, usecases.NewValidationError("invalid_query", "query parameters are invalid", entities.ValidationErrors{
+				{Field: "limit", Message: fmt.Sprintf("must be an integer between 1 and %d", repositories.MaxPageLimit)},
+			}))
+			return
+		}
+		query.Limit = limit
+	}
+
+	page, err := h.userUseCase.SearchProcessedUsers(c.Request.Context(), query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":  users,
-		"count": len(users),
+		"data":        page.Items,
+		"count":       len(page.Items),
+		"next_cursor": page.NextCursor,
 	})
 }
 
-func (h *UserHandler) HealthCheck(c *gin.Context) {
+func (h *UserHandler) GetStats(c *gin.Context) {
+	query, err := parseStatsQuery(c)
+	if err != nil {
+		respondError(c, usecases.NewValidationError("invalid_query", "query parameters are invalid", err))
+		return
+	}
+
+	stats, err := h.userUseCase.GetStats(c.Request.Context(), query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+func (h *UserHandler) GetProcessedUser(c *gin.Context) {
+	user, err := h.userUseCase.GetProcessedUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(user.Version))
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+func (h *UserHandler) UpdateProcessedUser(c *gin.Context) {
+	version, ok := expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	var update entities.ProcessedUserUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		respondError(c, usecases.NewValidationError("invalid_json", "request body is not valid JSON", err))
+		return
+	}
+
+	user, err := h.userUseCase.UpdateProcessedUser(c.Request.Context(), c.Param("id"), update, version)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(user.Version))
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+func (h *UserHandler) PatchProcessedUser(c *gin.Context) {
+	version, ok := expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	var patch entities.ProcessedUserPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		respondError(c, usecases.NewValidationError("invalid_json", "request body is not valid JSON", err))
+		return
+	}
+
+	user, err := h.userUseCase.PatchProcessedUser(c.Request.Context(), c.Param("id"), patch, version)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(user.Version))
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+func (h *UserHandler) DeleteProcessedUser(c *gin.Context) {
+	version, ok := expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userUseCase.DeleteProcessedUser(c.Request.Context(), c.Param("id"), version); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *UserHandler) GetProcessedUserHistory(c *gin.Context) {
+	limit := repositories.DefaultHistoryLimit
+	if v := c.Query("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > repositories.MaxHistoryLimit {
+			respondError(c, usecases.NewValidationError("invalid_query", "query parameters are invalid", entities.ValidationErrors{
+				{Field: "limit", Message: fmt.Sprintf("must be an integer between 1 and %d", repositories.MaxHistoryLimit)},
+			}))
+			return
+		}
+		limit = parsed
+	}
+
+	events, err := h.userUseCase.GetProcessedUserHistory(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status":          "ok",
-		"rabbitmq_status": h.rabbitMQService.IsConnected(),
-		"message":         "API is running",
+		"data":  events,
+		"count": len(events),
 	})
 }
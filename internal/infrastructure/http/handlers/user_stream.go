@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/domain/repositories"
+)
+
+// streamHeartbeat intervalo dos comentários que mantêm a conexão aberta em proxies
+const streamHeartbeat = 15 * time.Second
+
+// StreamProcessedUsers entrega as alterações como Server-Sent Events. O id de cada
+// evento é o token de retomada: ao reconectar, o navegador o envia em Last-Event-ID
+// (ou o cliente pode usar ?resume_token=) e recebe o que perdeu.
+func (h *UserHandler) StreamProcessedUsers(c *gin.Context) {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	stop := context.AfterFunc(h.streams, cancel)
+	defer stop()
+
+	query := repositories.WatchQuery{
+		Status:      c.Query("status"),
+		ResumeToken: c.GetHeader("Last-Event-ID"),
+	}
+	if token := c.Query("resume_token"); token != "" {
+		query.ResumeToken = token
+	}
+
+	stream, err := h.userUseCase.WatchProcessedUsers(ctx, query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	changes := make(chan *repositories.UserChange)
+	streamErr := make(chan error, 1)
+	readerDone := make(chan struct{})
+	// O stream não é seguro para uso concorrente: só é fechado depois que o leitor,
+	// interrompido pelo cancelamento, sai de Next
+	defer func() {
+		cancel()
+		<-readerDone
+		stream.Close(context.WithoutCancel(ctx))
+	}()
+
+	go func() {
+		defer close(readerDone)
+		defer close(changes)
+		for {
+			change, err := stream.Next(ctx)
+			if err != nil {
+				streamErr <- err
+				return
+			}
+			select {
+			case changes <- change:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	disableWriteTimeout(c)
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			return true
+		case change, ok := <-changes:
+			if !ok {
+				err := <-streamErr
+				if !errors.Is(err, context.Canceled) {
+					log.Printf("Live feed closed: %v", err)
+					fmt.Fprint(w, "event: error\ndata: {\"code\":\"stream_interrupted\"}\n\n")
+				}
+				return false
+			}
+			data, err := json.Marshal(change)
+			if err != nil {
+				log.Printf("Failed to encode change event: %v", err)
+				return true
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", change.ResumeToken, change.Type, data)
+			return true
+		}
+	})
+}
+
+// disableWriteTimeout libera do WriteTimeout do servidor as respostas que duram
+// mais que ele (fluxos e exportações)
+func disableWriteTimeout(c *gin.Context) {
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Warning: failed to clear write deadline: %v", err)
+	}
+}
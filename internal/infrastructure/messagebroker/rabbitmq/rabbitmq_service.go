@@ -3,24 +3,50 @@ package rabbitmq
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"api-rabbitmq/internal/application/usecases"
 	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/infrastructure/config"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+const (
+	userDataQueue = "user_data_queue"
+	// TenantHeader cabeçalho AMQP com o tenant dono da mensagem
+	TenantHeader = "x-tenant-id"
+)
+
 type RabbitMQService struct {
 	conn        *amqp.Connection
 	channel     *amqp.Channel
 	userUseCase usecases.UserUseCase
-	connected   bool
+	tenancy     *config.TenancyConfig
+	// queues filas consumidas e o tenant de cada uma ("" na fila compartilhada)
+	queues    map[string]string
+	connected bool
+
+	// Consumo, encerrado por StopConsuming
+	consumerTags []string
+	consumers    sync.WaitGroup
+	stopping     atomic.Bool
+	// running consumidores cujo canal de entregas ainda está aberto
+	running atomic.Int32
+	// processing é o contexto das mensagens em processamento, cancelado se o prazo
+	// de encerramento expirar
+	processing       context.Context
+	cancelProcessing context.CancelFunc
 }
 
-func NewRabbitMQService(rabbitMQURL string, userUseCase usecases.UserUseCase) (*RabbitMQService, error) {
+// NewRabbitMQService conecta e declara a fila compartilhada e as filas dedicadas de tenancy
+func NewRabbitMQService(rabbitMQURL string, userUseCase usecases.UserUseCase, tenancy *config.TenancyConfig) (*RabbitMQService, error) {
 	conn, err := amqp.Dial(rabbitMQURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
@@ -32,55 +58,98 @@ func NewRabbitMQService(rabbitMQURL string, userUseCase usecases.UserUseCase) (*
 		return nil, fmt.Errorf("failed to open channel: %v", err)
 	}
 
-	_, err = channel.QueueDeclare(
-		"user_data_queue",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		channel.Close()
-		conn.Close()
-		return nil, fmt.Errorf("failed to declare queue: %v", err)
+	queues := map[string]string{userDataQueue: ""}
+	for _, tenant := range tenancy.All() {
+		if queue := tenancy.QueueName(userDataQueue, tenant); queue != userDataQueue {
+			queues[queue] = tenant
+		}
 	}
 
+	for queue := range queues {
+		_, err = channel.QueueDeclare(
+			queue,
+			true,
+			false,
+			false,
+			false,
+			nil,
+		)
+		if err != nil {
+			channel.Close()
+			conn.Close()
+			return nil, fmt.Errorf("failed to declare queue %s: %v", queue, err)
+		}
+	}
+
+	processing, cancelProcessing := context.WithCancel(context.Background())
 	return &RabbitMQService{
-		conn:        conn,
-		channel:     channel,
-		userUseCase: userUseCase,
-		connected:   true,
+		conn:             conn,
+		channel:          channel,
+		userUseCase:      userUseCase,
+		tenancy:          tenancy,
+		queues:           queues,
+		connected:        true,
+		processing:       processing,
+		cancelProcessing: cancelProcessing,
 	}, nil
 }
 
+// ConsumeMessages registra um consumidor por fila e processa as mensagens em segundo plano
 func (s *RabbitMQService) ConsumeMessages() error {
 	err := s.channel.Qos(1, 0, false)
 	if err != nil {
 		return fmt.Errorf("failed to set QoS: %v", err)
 	}
 
-	msgs, err := s.channel.Consume(
-		"user_data_queue",
-		"",
-		false,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to register consumer: %v", err)
-	}
+	for queue, tenant := range s.queues {
+		// Tag conhecida, para que StopConsuming possa cancelar o consumidor
+		tag := fmt.Sprintf("%s-%d", queue, os.Getpid())
+		msgs, err := s.channel.Consume(
+			queue,
+			tag,
+			false,
+			false,
+			false,
+			false,
+			nil,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to register consumer on %s: %v", queue, err)
+		}
 
-	go s.processMessages(msgs)
-	log.Printf("Waiting for messages on queue: user_data_queue")
+		s.consumerTags = append(s.consumerTags, tag)
+		s.consumers.Add(1)
+		s.running.Add(1)
+		go func(queue, tenant string) {
+			defer s.consumers.Done()
+			defer s.running.Add(-1)
+			s.processMessages(queue, tenant, msgs)
+		}(queue, tenant)
+		log.Printf("Waiting for messages on queue: %s", queue)
+	}
 	return nil
 }
 
-func (s *RabbitMQService) processMessages(msgs <-chan amqp.Delivery) {
+// processMessages consome uma fila; queueTenant é o tenant de uma fila dedicada
+func (s *RabbitMQService) processMessages(queue, queueTenant string, msgs <-chan amqp.Delivery) {
+	// Ator registrado na auditoria para as alterações feitas pelo consumidor
+	actor := entities.AuditActor{Type: entities.ActorConsumer, ID: queue}
+
 	for msg := range msgs {
-		ctx := context.Background()
+		if s.stopping.Load() {
+			// Pré-carregada antes do cancelamento: volta para a fila sem ser processada
+			msg.Nack(false, true)
+			continue
+		}
+
+		tenant, err := s.messageTenant(msg, queueTenant)
+		if err != nil {
+			log.Printf("Discarding message: %v", err)
+			msg.Nack(false, false)
+			continue
+		}
+		ctx := entities.WithAudit(s.processing, actor, messageCorrelationID(msg))
+		ctx = entities.WithTenant(ctx, tenant)
 
 		var userData entities.UserData
 		if err := json.Unmarshal(msg.Body, &userData); err != nil {
@@ -89,19 +158,78 @@ func (s *RabbitMQService) processMessages(msgs <-chan amqp.Delivery) {
 			continue
 		}
 
-		_, err := s.userUseCase.ProcessUser(ctx, userData)
-		if err != nil {
+		if err := userData.Validate(); err != nil {
+			log.Printf("Discarding invalid message: %v", err)
+			msg.Nack(false, false)
+			continue
+		}
+
+		result, err := s.userUseCase.ProcessUser(ctx, userData)
+		if err != nil && !isRetryable(err) {
+			// Reprocessar não resolve mensagens inválidas, CEPs inexistentes ou duplicados rejeitados
+			log.Printf("Discarding message after non-retryable error: %v", err)
+			msg.Nack(false, false)
+		} else if err != nil {
 			log.Printf("Error processing message: %v", err)
 			msg.Nack(false, true)
 		} else {
-			log.Printf("Message processed successfully")
+			log.Printf("Message processed successfully (%s)", result.Outcome)
 			msg.Ack(false)
 		}
 	}
 }
 
-func (s *RabbitMQService) PublishMessage(userData entities.UserData) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// messageTenant resolve o tenant pelo cabeçalho x-tenant-id. Na fila compartilhada,
+// mensagens sem o cabeçalho (publicadas antes da multi-tenancy) pertencem ao tenant
+// padrão. Em uma fila dedicada o cabeçalho é opcional, mas se presente deve ser o
+// tenant da fila.
+func (s *RabbitMQService) messageTenant(msg amqp.Delivery, queueTenant string) (string, error) {
+	header, _ := msg.Headers[TenantHeader].(string)
+
+	switch {
+	case !s.tenancy.Enabled:
+		return entities.DefaultTenant, nil
+	case queueTenant != "" && (header == "" || header == queueTenant):
+		return queueTenant, nil
+	case queueTenant != "":
+		return "", fmt.Errorf("message for tenant %q on the queue of tenant %q", header, queueTenant)
+	case header == "":
+		return entities.DefaultTenant, nil
+	case !s.tenancy.Known(header):
+		return "", fmt.Errorf("message for unknown tenant %q", header)
+	}
+	return header, nil
+}
+
+// messageCorrelationID continua a correlação iniciada na publicação
+func messageCorrelationID(msg amqp.Delivery) string {
+	if msg.CorrelationId != "" {
+		return msg.CorrelationId
+	}
+	if msg.MessageId != "" {
+		return msg.MessageId
+	}
+	return entities.NewCorrelationID()
+}
+
+// isRetryable indica se vale devolver a mensagem para a fila
+func isRetryable(err error) bool {
+	return !errors.Is(err, usecases.ErrValidation) &&
+		!errors.Is(err, usecases.ErrNotFound) &&
+		!errors.Is(err, usecases.ErrConflict)
+}
+
+// PublishMessage publica a mensagem levando o ID de correlação e o tenant de ctx (ver
+// entities.WithAudit e entities.WithTenant), na fila dedicada do tenant se houver
+func (s *RabbitMQService) PublishMessage(ctx context.Context, userData entities.UserData) error {
+	correlationID := entities.AuditFromContext(ctx).CorrelationID
+	tenant, ok := entities.TenantFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("cannot publish message without a tenant")
+	}
+	queue := s.tenancy.QueueName(userDataQueue, tenant)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	body, err := json.Marshal(userData)
@@ -111,24 +239,54 @@ func (s *RabbitMQService) PublishMessage(userData entities.UserData) error {
 
 	err = s.channel.PublishWithContext(ctx,
 		"",
-		"user_data_queue",
+		queue,
 		false,
 		false,
 		amqp.Publishing{
-			DeliveryMode: amqp.Persistent,
-			ContentType:  "application/json",
-			Body:         body,
-			Timestamp:    time.Now(),
+			DeliveryMode:  amqp.Persistent,
+			ContentType:   "application/json",
+			CorrelationId: correlationID,
+			Headers:       amqp.Table{TenantHeader: tenant},
+			Body:          body,
+			Timestamp:     time.Now(),
 		})
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %v", err)
 	}
 
-	log.Printf("Published message to queue")
+	log.Printf("Published message to queue %s", queue)
 	return nil
 }
 
+// StopConsuming cancela os consumidores, para que o broker não entregue novas
+// mensagens, e espera as mensagens em processamento serem confirmadas. Se ctx
+// expirar antes, o processamento em curso é cancelado e as mensagens não
+// confirmadas voltam para a fila quando o canal for fechado.
+func (s *RabbitMQService) StopConsuming(ctx context.Context) error {
+	s.stopping.Store(true)
+	for _, tag := range s.consumerTags {
+		if err := s.channel.Cancel(tag, false); err != nil {
+			log.Printf("Warning: failed to cancel consumer %s: %v", tag, err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.consumers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancelProcessing()
+		return fmt.Errorf("in-flight messages did not finish: %w", ctx.Err())
+	}
+}
+
 func (s *RabbitMQService) Close() {
+	s.cancelProcessing()
 	if s.channel != nil {
 		s.channel.Close()
 	}
@@ -138,6 +296,30 @@ func (s *RabbitMQService) Close() {
 	s.connected = false
 }
 
+// CheckConnection verifica se a conexão e o canal AMQP estão abertos
+func (s *RabbitMQService) CheckConnection(ctx context.Context) (any, error) {
+	if !s.IsConnected() {
+		return nil, errors.New("connection is closed")
+	}
+	if s.channel == nil || s.channel.IsClosed() {
+		return nil, errors.New("channel is closed")
+	}
+	return nil, nil
+}
+
+// CheckConsumers verifica se há um consumidor ativo em cada fila; o broker encerra
+// as entregas de um consumidor quando a conexão ou o canal caem
+func (s *RabbitMQService) CheckConsumers(ctx context.Context) (any, error) {
+	details := map[string]any{"running": s.running.Load(), "expected": len(s.queues)}
+	if s.stopping.Load() {
+		return details, errors.New("consumers are shutting down")
+	}
+	if int(s.running.Load()) < len(s.queues) {
+		return details, fmt.Errorf("%d of %d consumers running", s.running.Load(), len(s.queues))
+	}
+	return details, nil
+}
+
 func (s *RabbitMQService) IsConnected() bool {
 	return s.connected && s.conn != nil && !s.conn.IsClosed()
 }
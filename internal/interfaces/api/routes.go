@@ -3,21 +3,41 @@ package api
 import (
 	"github.com/gin-gonic/gin"
 
+	"api-rabbitmq/internal/infrastructure/config"
 	"api-rabbitmq/internal/infrastructure/http/handlers"
 )
 
-func SetupRoutes(router *gin.Engine, userHandler *handlers.UserHandler) {
-	// Health check
-	router.GET("/health", userHandler.HealthCheck)
+func SetupRoutes(router *gin.Engine, tenancy *config.TenancyConfig, userHandler *handlers.UserHandler, privacyHandler *handlers.PrivacyHandler, retentionHandler *handlers.RetentionHandler, healthHandler *handlers.HealthHandler) {
+	router.Use(handlers.AccessLog(), handlers.RequestContext())
+
+	// Health checks: liveness sem dependências, readiness com as verificações registradas
+	router.GET("/livez", healthHandler.Live)
+	router.GET("/readyz", healthHandler.Ready)
+	// Mantido por compatibilidade, com a resposta original
+	router.GET("/health", healthHandler.Health)
 
 	// API v1
 	v1 := router.Group("/api/v1")
+	v1.Use(handlers.Tenant(tenancy))
 	{
 		// Users
 		users := v1.Group("/users")
 		{
 			users.POST("/publish", userHandler.PublishUser)
 			users.GET("/processed", userHandler.GetProcessedUsers)
+			users.GET("/export", userHandler.ExportProcessedUsers)
+			users.GET("/search", userHandler.SearchProcessedUsers)
+			users.GET("/stats", userHandler.GetStats)
+			users.GET("/stream", userHandler.StreamProcessedUsers)
+			users.GET("/retention/report", retentionHandler.Report)
+			users.GET("/:id", userHandler.GetProcessedUser)
+			users.PUT("/:id", userHandler.UpdateProcessedUser)
+			users.PATCH("/:id", userHandler.PatchProcessedUser)
+			users.DELETE("/:id", userHandler.DeleteProcessedUser)
+			users.GET("/:id/history", userHandler.GetProcessedUserHistory)
+
+			// LGPD: eliminação de todos os dados de um titular
+			users.DELETE("/by-document/:document", privacyHandler.EraseSubject)
 		}
 	}
 }
@@ -0,0 +1,373 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"api-rabbitmq/internal/application/usecases"
+	"api-rabbitmq/internal/domain/entities"
+	"api-rabbitmq/internal/domain/repositories"
+	"api-rabbitmq/internal/infrastructure/archive"
+	"api-rabbitmq/internal/infrastructure/config"
+	"api-rabbitmq/internal/infrastructure/database/inmemory"
+	"api-rabbitmq/internal/infrastructure/health"
+	"api-rabbitmq/internal/infrastructure/http/handlers"
+	"api-rabbitmq/internal/interfaces/api"
+)
+
+const (
+	cpfDigits    = "52998224725"
+	cpfFormatted = "529.982.247-25"
+)
+
+// fakeExternalServices valida qualquer documento e devolve um endereço fixo
+type fakeExternalServices struct{}
+
+func (fakeExternalServices) ValidateDocument(ctx context.Context, documentNumber string) (bool, error) {
+	return true, nil
+}
+
+func (fakeExternalServices) GetAddress(ctx context.Context, zipCode entities.ZipCode) (*entities.AddressResponse, error) {
+	return &entities.AddressResponse{Street: "Rua das Flores", City: "Campinas", State: "SP", Zipcode: zipCode.String()}, nil
+}
+
+// inlinePublisher processa a mensagem na hora, como o consumidor faria, para que os
+// logs do processamento também sejam verificados
+type inlinePublisher struct {
+	userUseCase usecases.UserUseCase
+	err         error
+}
+
+func (p inlinePublisher) PublishMessage(ctx context.Context, userData entities.UserData) error {
+	if p.err != nil {
+		return p.err
+	}
+	_, err := p.userUseCase.ProcessUser(ctx, userData)
+	return err
+}
+
+func (p inlinePublisher) IsConnected() bool {
+	return p.err == nil
+}
+
+// failingTarget simula um armazenamento indisponível durante a eliminação
+type failingTarget struct{}
+
+func (failingTarget) Name() string { return "unavailable_store" }
+
+func (failingTarget) EraseSubject(ctx context.Context, documentNumber string, mode repositories.ErasureMode) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+type routerOptions struct {
+	publishErr   error
+	failErasures bool
+}
+
+func newRouter(t *testing.T, opts routerOptions) *gin.Engine {
+	t.Helper()
+
+	auditRepo := inmemory.NewAuditRepository()
+	userRepo, err := inmemory.NewUserRepository(
+		&config.DatabaseConfig{CollectionName: "processed_users", DedupePolicy: string(repositories.DedupeUpsert)},
+		inmemory.UserRepositoryOptions{Audit: auditRepo})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archiver := archive.NewNDJSONArchiver(t.TempDir(), "subject-key")
+	targets := []repositories.ErasureTarget{userRepo, auditRepo, archiver}
+	if opts.failErasures {
+		targets = append(targets, failingTarget{})
+	}
+
+	userUseCase := usecases.NewUserUseCase(userRepo, auditRepo, fakeExternalServices{})
+	privacyUseCase := usecases.NewPrivacyUseCase(inmemory.NewErasureReceiptRepository(), repositories.ErasureDelete, "subject-key", targets...)
+	retentionUseCase := usecases.NewRetentionUseCase(userRepo, archiver, usecases.RetentionArchive, 24*time.Hour)
+
+	publisher := inlinePublisher{userUseCase: userUseCase, err: opts.publishErr}
+	router := gin.New()
+	router.Use(gin.Recovery())
+	api.SetupRoutes(router, &config.TenancyConfig{},
+		handlers.NewUserHandler(userUseCase, publisher),
+		handlers.NewPrivacyHandler(privacyUseCase),
+		handlers.NewRetentionHandler(retentionUseCase),
+		handlers.NewHealthHandler(health.NewRegistry(time.Second), publisher))
+	return router
+}
+
+// captureLogs redireciona o log padrão e a saída do Gin durante o teste
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logWriter, ginWriter, ginErrorWriter := log.Writer(), gin.DefaultWriter, gin.DefaultErrorWriter
+	log.SetOutput(&buf)
+	gin.DefaultWriter, gin.DefaultErrorWriter = &buf, &buf
+	t.Cleanup(func() {
+		log.SetOutput(logWriter)
+		gin.DefaultWriter, gin.DefaultErrorWriter = ginWriter, ginErrorWriter
+	})
+	return &buf
+}
+
+func TestLogsDoNotContainDocumentNumbers(t *testing.T) {
+	gin.SetMode(gin.DebugMode)
+	entities.SetPIIMasking(true)
+
+	publishBody := `{"name":"Maria da Silva","document_number":"` + cpfFormatted + `","zipCode":"13086-656"}`
+
+	tests := []struct {
+		name       string
+		opts       routerOptions
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "publish", method: http.MethodPost, path: "/api/v1/users/publish", body: publishBody, wantStatus: http.StatusOK},
+		{name: "publish queue unavailable", opts: routerOptions{publishErr: errors.New("channel closed")},
+			method: http.MethodPost, path: "/api/v1/users/publish", body: publishBody, wantStatus: http.StatusServiceUnavailable},
+		{name: "erase", method: http.MethodDelete, path: "/api/v1/users/by-document/" + cpfDigits, wantStatus: http.StatusOK},
+		{name: "erase formatted", method: http.MethodDelete, path: "/api/v1/users/by-document/" + cpfFormatted, wantStatus: http.StatusOK},
+		{name: "erase store unavailable", opts: routerOptions{failErasures: true},
+			method: http.MethodDelete, path: "/api/v1/users/by-document/" + cpfDigits, wantStatus: http.StatusServiceUnavailable},
+		{name: "unmatched route", method: http.MethodGet, path: "/api/v1/users/by-document/" + cpfDigits + "/receipt", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			router := newRouter(t, tt.opts)
+
+			// Um registro do titular, para que a eliminação tenha o que apagar
+			seed := httptest.NewRecorder()
+			router.ServeHTTP(seed, httptest.NewRequest(http.MethodPost, "/api/v1/users/publish", strings.NewReader(publishBody)))
+
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, body))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if logs.Len() == 0 {
+				t.Fatal("no log output captured")
+			}
+			for _, raw := range []string{cpfDigits, cpfFormatted, "Maria da Silva", "13086-656", "13086656"} {
+				if strings.Contains(logs.String(), raw) {
+					t.Errorf("log output contains %q:\n%s", raw, logs.String())
+				}
+			}
+		})
+	}
+}
+
+func TestLegacyHealthKeepsItsResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range []struct {
+		name      string
+		opts      routerOptions
+		connected bool
+	}{
+		{name: "connected", connected: true},
+		{name: "broker down", opts: routerOptions{publishErr: errors.New("channel closed")}, connected: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			newRouter(t, tt.opts).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]any{"status": "ok", "rabbitmq_status": tt.connected, "message": "API is running"}
+			if !reflect.DeepEqual(body, want) {
+				t.Fatalf("body = %v, want %v", body, want)
+			}
+		})
+	}
+}
+
+// serve executa uma requisição com os cabeçalhos informados (nome, valor, ...)
+func serve(router *gin.Engine, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// seedUser processa um usuário e retorna seu ID e ETag
+func seedUser(t *testing.T, router *gin.Engine) (id, etag string) {
+	t.Helper()
+
+	publish := `{"name":"Maria da Silva","document_number":"` + cpfDigits + `","zipCode":"13086-656"}`
+	if rec := serve(router, http.MethodPost, "/api/v1/users/publish", publish); rec.Code != http.StatusOK {
+		t.Fatalf("publish status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var page struct {
+		Data []entities.ProcessedUser `json:"data"`
+	}
+	rec := serve(router, http.MethodGet, "/api/v1/users/processed", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil || len(page.Data) != 1 {
+		t.Fatalf("processed users = %s (err %v), want one user", rec.Body.String(), err)
+	}
+	id = page.Data[0].ID.Hex()
+
+	rec = serve(router, http.MethodGet, "/api/v1/users/"+id, "")
+	if etag = rec.Header().Get("ETag"); etag == "" {
+		t.Fatalf("GET %s returned no ETag", id)
+	}
+	return id, etag
+}
+
+func TestWritesRequireTheCurrentStrongETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const (
+		update = `{"name":"Maria Silva","document":{"document_number":"` + cpfDigits + `","is_valid":true},` +
+			`"address":{"street":"Rua das Flores","city":"Campinas","state":"SP","zipcode":"13086656"},` +
+			`"status":"processed","message":"corrected"}`
+		patch = `{"name":"Maria Silva"}`
+	)
+
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		ifMatch     func(etag string) string
+		wantCode    int
+		wantProblem string
+	}{
+		{name: "patch without If-Match", method: http.MethodPatch, body: patch, wantCode: http.StatusPreconditionRequired, wantProblem: "precondition_required"},
+		{name: "put without If-Match", method: http.MethodPut, body: update, wantCode: http.StatusPreconditionRequired, wantProblem: "precondition_required"},
+		{name: "delete without If-Match", method: http.MethodDelete, wantCode: http.StatusPreconditionRequired, wantProblem: "precondition_required"},
+		{name: "patch with a weak ETag", method: http.MethodPatch, body: patch,
+			ifMatch: func(etag string) string { return "W/" + etag }, wantCode: http.StatusPreconditionFailed, wantProblem: "weak_etag"},
+		{name: "patch with a stale ETag", method: http.MethodPatch, body: patch,
+			ifMatch: func(string) string { return `"99"` }, wantCode: http.StatusPreconditionFailed, wantProblem: "version_mismatch"},
+		{name: "put with a stale ETag", method: http.MethodPut, body: update,
+			ifMatch: func(string) string { return `"99"` }, wantCode: http.StatusPreconditionFailed, wantProblem: "version_mismatch"},
+		{name: "delete with a stale ETag", method: http.MethodDelete,
+			ifMatch: func(string) string { return `"99"` }, wantCode: http.StatusPreconditionFailed, wantProblem: "version_mismatch"},
+		{name: "malformed If-Match", method: http.MethodPatch, body: patch,
+			ifMatch: func(string) string { return "latest" }, wantCode: http.StatusBadRequest, wantProblem: "invalid_if_match"},
+		{name: "patch with the current ETag", method: http.MethodPatch, body: patch,
+			ifMatch: func(etag string) string { return etag }, wantCode: http.StatusOK},
+		{name: "put with any version", method: http.MethodPut, body: update,
+			ifMatch: func(string) string { return "*" }, wantCode: http.StatusOK},
+		{name: "delete with the current ETag", method: http.MethodDelete,
+			ifMatch: func(etag string) string { return etag }, wantCode: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRouter(t, routerOptions{})
+			id, etag := seedUser(t, router)
+
+			var headers []string
+			if tt.ifMatch != nil {
+				headers = []string{"If-Match", tt.ifMatch(etag)}
+			}
+			rec := serve(router, tt.method, "/api/v1/users/"+id, tt.body, headers...)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+
+			if tt.wantProblem != "" {
+				var problem handlers.Problem
+				if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+					t.Fatal(err)
+				}
+				if problem.Code != tt.wantProblem {
+					t.Fatalf("problem code = %q, want %q", problem.Code, tt.wantProblem)
+				}
+				// A escrita rejeitada não altera o registro
+				if got := serve(router, http.MethodGet, "/api/v1/users/"+id, "").Header().Get("ETag"); got != etag {
+					t.Fatalf("ETag after a rejected write = %s, want %s", got, etag)
+				}
+			}
+			if rec.Code == http.StatusOK && rec.Header().Get("ETag") == etag {
+				t.Fatalf("successful write kept the ETag %s", etag)
+			}
+		})
+	}
+}
+
+func TestUserWritesNormalizeTheZipCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	address := func(zipcode string) string {
+		return `{"address":{"street":"Rua das Flores","city":"Campinas","state":"SP","zipcode":"` + zipcode + `"}}`
+	}
+
+	for _, tt := range []struct {
+		name        string
+		zipcode     string
+		wantCode    int
+		wantZipcode string
+	}{
+		{name: "hyphenated", zipcode: "01310-100", wantCode: http.StatusOK, wantZipcode: "01310100"},
+		{name: "digits", zipcode: "13086656", wantCode: http.StatusOK, wantZipcode: "13086656"},
+		{name: "missing", zipcode: "", wantCode: http.StatusBadRequest},
+		{name: "too short", zipcode: "1308665", wantCode: http.StatusBadRequest},
+		{name: "out of range", zipcode: "00999-999", wantCode: http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRouter(t, routerOptions{})
+			id, _ := seedUser(t, router)
+
+			rec := serve(router, http.MethodPatch, "/api/v1/users/"+id, address(tt.zipcode), "If-Match", "*")
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				var problem handlers.Problem
+				if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+					t.Fatal(err)
+				}
+				if len(problem.Errors) != 1 || problem.Errors[0].Field != "address.zipcode" {
+					t.Fatalf("field errors = %+v, want one for address.zipcode", problem.Errors)
+				}
+				return
+			}
+
+			var body struct {
+				Data entities.ProcessedUser `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Data.Address.Zipcode != tt.wantZipcode {
+				t.Fatalf("stored zipcode = %q, want %q", body.Data.Address.Zipcode, tt.wantZipcode)
+			}
+		})
+	}
+}